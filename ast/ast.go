@@ -10,6 +10,7 @@ import (
 
 type Node interface {
 	TokenLiteral() string
+	Position() (line, column int) // position of the node's token, 0 if unknown
 	String() string
 }
 
@@ -35,6 +36,13 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Position() (int, int) {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Position()
+	}
+	return 0, 0
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 
@@ -47,13 +55,15 @@ func (p *Program) String() string {
 }
 
 type LetStatement struct {
-	Token token.Token // the token.LET token
+	Token token.Token // the token.LET or token.CONST token
 	Name  *Identifier
 	Value Expression
 }
 
 func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
+func (ls *LetStatement) Position() (int, int) { return ls.Token.Line, ls.Token.Column }
+func (ls *LetStatement) IsConst() bool        { return ls.Token.Type == token.CONST }
 
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
@@ -78,6 +88,7 @@ type ReturnStatement struct {
 
 func (rs *ReturnStatement) statementNode()       {}
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
+func (rs *ReturnStatement) Position() (int, int) { return rs.Token.Line, rs.Token.Column }
 
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
@@ -93,6 +104,33 @@ func (rs *ReturnStatement) String() string {
 	return out.String()
 }
 
+type StructStatement struct {
+	Token  token.Token // the token.STRUCT token
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (ss *StructStatement) statementNode()       {}
+func (ss *StructStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *StructStatement) Position() (int, int) { return ss.Token.Line, ss.Token.Column }
+
+func (ss *StructStatement) String() string {
+	var out bytes.Buffer
+
+	fields := []string{}
+	for _, f := range ss.Fields {
+		fields = append(fields, f.String())
+	}
+
+	out.WriteString(ss.TokenLiteral() + " ")
+	out.WriteString(ss.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
 type ExpressionStatement struct {
 	Token      token.Token // the first token of the expression
 	Expression Expression
@@ -100,6 +138,7 @@ type ExpressionStatement struct {
 
 func (es *ExpressionStatement) statementNode()       {}
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *ExpressionStatement) Position() (int, int) { return es.Token.Line, es.Token.Column }
 
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
@@ -115,6 +154,7 @@ type BlockStatement struct {
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Position() (int, int) { return bs.Token.Line, bs.Token.Column }
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -132,6 +172,7 @@ type Identifier struct {
 
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+func (i *Identifier) Position() (int, int) { return i.Token.Line, i.Token.Column }
 func (i *Identifier) String() string       { return i.Value }
 
 type PrefixExpression struct {
@@ -142,6 +183,7 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Position() (int, int) { return pe.Token.Line, pe.Token.Column }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -162,6 +204,7 @@ type InfixExpression struct {
 
 func (ie *InfixExpression) expressionNode()      {}
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *InfixExpression) Position() (int, int) { return ie.Token.Line, ie.Token.Column }
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -183,6 +226,7 @@ type IfExpression struct {
 
 func (ie *IfExpression) expressionNode()      {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Position() (int, int) { return ie.Token.Line, ie.Token.Column }
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -207,6 +251,7 @@ type CallExpression struct {
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Position() (int, int) { return ce.Token.Line, ce.Token.Column }
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -232,6 +277,7 @@ type IndexExpression struct {
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Position() (int, int) { return ie.Token.Line, ie.Token.Column }
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
@@ -244,18 +290,70 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+type SliceExpression struct {
+	Token token.Token // the '[' token
+	Left  Expression
+	Start Expression // nil when omitted
+	End   Expression // nil when omitted
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) Position() (int, int) { return se.Token.Line, se.Token.Column }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	out.WriteString("])")
+
+	return out.String()
+}
+
+type MemberExpression struct {
+	Token    token.Token // the '.' token
+	Object   Expression
+	Property *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) Position() (int, int) { return me.Token.Line, me.Token.Column }
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(me.Object.String())
+	out.WriteString(".")
+	out.WriteString(me.Property.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
+func (il *IntegerLiteral) Position() (int, int) { return il.Token.Line, il.Token.Column }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
 type FunctionLiteral struct {
-	Token      token.Token // the 'fn' token
-	Parameters []*Identifier
-	Body       *BlockStatement
+	Token       token.Token // the 'fn' token, or '=>' for arrow functions
+	Parameters  []*Identifier
+	Body        *BlockStatement
+	IsGenerator bool // set if the body contains a yield outside nested function literals
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) Position() (int, int) { return fl.Token.Line, fl.Token.Column }
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
@@ -265,6 +363,14 @@ func (fl *FunctionLiteral) String() string {
 		params = append(params, p.String())
 	}
 
+	if fl.Token.Type == token.ARROW {
+		out.WriteString("(")
+		out.WriteString(strings.Join(params, ", "))
+		out.WriteString(") => ")
+		out.WriteString(fl.Body.String())
+		return out.String()
+	}
+
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
@@ -274,6 +380,45 @@ func (fl *FunctionLiteral) String() string {
 	return out.String()
 }
 
+type YieldExpression struct {
+	Token token.Token // the 'yield' token
+	Value Expression
+}
+
+func (ye *YieldExpression) expressionNode()      {}
+func (ye *YieldExpression) TokenLiteral() string { return ye.Token.Literal }
+func (ye *YieldExpression) Position() (int, int) { return ye.Token.Line, ye.Token.Column }
+func (ye *YieldExpression) String() string {
+	return ye.TokenLiteral() + " " + ye.Value.String()
+}
+
+// PipeExpression passes Left as the first argument to Right: x |> f(a) is
+// f(x, a) and x |> f is f(x).
+type PipeExpression struct {
+	Token token.Token // the '|>' token
+	Left  Expression
+	Right Expression
+}
+
+func (pe *PipeExpression) expressionNode()      {}
+func (pe *PipeExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PipeExpression) Position() (int, int) { return pe.Token.Line, pe.Token.Column }
+func (pe *PipeExpression) String() string {
+	return "(" + pe.Left.String() + " |> " + pe.Right.String() + ")"
+}
+
+type SpawnExpression struct {
+	Token token.Token // the 'spawn' token
+	Call  *CallExpression
+}
+
+func (se *SpawnExpression) expressionNode()      {}
+func (se *SpawnExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SpawnExpression) Position() (int, int) { return se.Token.Line, se.Token.Column }
+func (se *SpawnExpression) String() string {
+	return se.TokenLiteral() + " " + se.Call.String()
+}
+
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
@@ -286,8 +431,34 @@ type StringLiteral struct {
 
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *StringLiteral) Position() (int, int) { return sl.Token.Line, sl.Token.Column }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
 
+// InterpolatedString is a string literal with embedded expressions. Parts
+// holds the *StringLiteral text between the expressions and the expressions
+// in source order.
+type InterpolatedString struct {
+	Token token.Token // the first token.TEMPLATE token
+	Parts []Expression
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) Position() (int, int) { return is.Token.Line, is.Token.Column }
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	for _, part := range is.Parts {
+		if str, ok := part.(*StringLiteral); ok {
+			out.WriteString(str.Value)
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+
+	return out.String()
+}
+
 type ArrayLiteral struct {
 	Token    token.Token // the '[' token
 	Elements []Expression
@@ -295,6 +466,7 @@ type ArrayLiteral struct {
 
 func (al *ArrayLiteral) expressionNode()      {}
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Position() (int, int) { return al.Token.Line, al.Token.Column }
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 
@@ -313,17 +485,19 @@ func (al *ArrayLiteral) String() string {
 type HashLiteral struct {
 	Token token.Token // the '{' token
 	Pairs map[Expression]Expression
+	Keys  []Expression // keys of Pairs in source order
 }
 
 func (hl *HashLiteral) expressionNode()      {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Position() (int, int) { return hl.Token.Line, hl.Token.Column }
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
 
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 
 	out.WriteString("{")
@@ -340,4 +514,5 @@ type Boolean struct {
 
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+func (b *Boolean) Position() (int, int) { return b.Token.Line, b.Token.Column }
 func (b *Boolean) String() string       { return b.Token.Literal }
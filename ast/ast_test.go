@@ -3,7 +3,9 @@
 package ast
 
 import (
+	"fmt"
 	"github.com/solbero/monkey/token"
+	"strings"
 	"testing"
 )
 
@@ -28,3 +30,66 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong, got %q", program.String())
 	}
 }
+
+func TestInspect(t *testing.T) {
+	// let f = fn(x) { if (x) { x[0] } }; f(1)
+	x := func() *Identifier {
+		return &Identifier{Token: token.Token{Type: token.IDENT, Literal: "x"}, Value: "x"}
+	}
+	program := &Program{
+		Statements: []Statement{
+			&LetStatement{
+				Token: token.Token{Type: token.LET, Literal: "let"},
+				Name:  &Identifier{Token: token.Token{Type: token.IDENT, Literal: "f"}, Value: "f"},
+				Value: &FunctionLiteral{
+					Token:      token.Token{Type: token.FUNCTION, Literal: "fn"},
+					Parameters: []*Identifier{x()},
+					Body: &BlockStatement{Statements: []Statement{
+						&ExpressionStatement{Expression: &IfExpression{
+							Condition: x(),
+							Consequence: &BlockStatement{Statements: []Statement{
+								&ExpressionStatement{Expression: &IndexExpression{Left: x(), Index: &IntegerLiteral{Value: 0}}},
+							}},
+						}},
+					}},
+				},
+			},
+			&ExpressionStatement{Expression: &CallExpression{
+				Function:  &Identifier{Value: "f"},
+				Arguments: []Expression{&IntegerLiteral{Value: 1}},
+			}},
+		},
+	}
+
+	expected := []string{
+		"*ast.Program", "*ast.LetStatement", "*ast.Identifier f", "*ast.FunctionLiteral",
+		"*ast.Identifier x", "*ast.BlockStatement", "*ast.ExpressionStatement", "*ast.IfExpression",
+		"*ast.Identifier x", "*ast.BlockStatement", "*ast.ExpressionStatement", "*ast.IndexExpression",
+		"*ast.Identifier x", "*ast.IntegerLiteral", "*ast.ExpressionStatement", "*ast.CallExpression",
+		"*ast.Identifier f", "*ast.IntegerLiteral",
+	}
+
+	var visited []string
+	Inspect(program, func(node Node) bool {
+		name := fmt.Sprintf("%T", node)
+		if ident, ok := node.(*Identifier); ok {
+			name += " " + ident.Value
+		}
+		visited = append(visited, name)
+		return true
+	})
+
+	if strings.Join(visited, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("wrong nodes visited, expected\n%s\ngot\n%s", strings.Join(expected, ", "), strings.Join(visited, ", "))
+	}
+
+	count := 0
+	Inspect(program, func(node Node) bool {
+		count++
+		_, isFunction := node.(*FunctionLiteral)
+		return !isFunction
+	})
+	if count != 8 {
+		t.Errorf("children of skipped node visited, expected 8 nodes, got %d", count)
+	}
+}
@@ -0,0 +1,197 @@
+// ast/dump.go
+
+package ast
+
+import (
+	"fmt"
+
+	"github.com/solbero/monkey/token"
+)
+
+// Dump converts a syntax tree into maps, slices and values that encode as
+// JSON. Every node becomes a map with its type, position and children.
+func Dump(node Node) interface{} {
+	switch node := node.(type) {
+	case *Program:
+		return map[string]interface{}{
+			"type":       "Program",
+			"statements": dumpStatements(node.Statements),
+		}
+	case *LetStatement:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "LetStatement",
+			"const": node.IsConst(),
+			"name":  Dump(node.Name),
+			"value": Dump(node.Value),
+		})
+	case *ReturnStatement:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "ReturnStatement",
+			"value": Dump(node.ReturnValue),
+		})
+	case *StructStatement:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":   "StructStatement",
+			"name":   Dump(node.Name),
+			"fields": dumpIdentifiers(node.Fields),
+		})
+	case *ExpressionStatement:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":       "ExpressionStatement",
+			"expression": Dump(node.Expression),
+		})
+	case *BlockStatement:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":       "BlockStatement",
+			"statements": dumpStatements(node.Statements),
+		})
+	case *Identifier:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "Identifier",
+			"value": node.Value,
+		})
+	case *PrefixExpression:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":     "PrefixExpression",
+			"operator": node.Operator,
+			"right":    Dump(node.Right),
+		})
+	case *InfixExpression:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":     "InfixExpression",
+			"operator": node.Operator,
+			"left":     Dump(node.Left),
+			"right":    Dump(node.Right),
+		})
+	case *IfExpression:
+		var alternative interface{}
+		if node.Alternative != nil {
+			alternative = Dump(node.Alternative)
+		}
+		return withPosition(node.Token, map[string]interface{}{
+			"type":        "IfExpression",
+			"condition":   Dump(node.Condition),
+			"consequence": Dump(node.Consequence),
+			"alternative": alternative,
+		})
+	case *CallExpression:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":      "CallExpression",
+			"function":  Dump(node.Function),
+			"arguments": dumpExpressions(node.Arguments),
+		})
+	case *IndexExpression:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "IndexExpression",
+			"left":  Dump(node.Left),
+			"index": Dump(node.Index),
+		})
+	case *SliceExpression:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "SliceExpression",
+			"left":  Dump(node.Left),
+			"start": Dump(node.Start),
+			"end":   Dump(node.End),
+		})
+	case *MemberExpression:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":     "MemberExpression",
+			"object":   Dump(node.Object),
+			"property": Dump(node.Property),
+		})
+	case *FunctionLiteral:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":        "FunctionLiteral",
+			"parameters":  dumpIdentifiers(node.Parameters),
+			"body":        Dump(node.Body),
+			"isGenerator": node.IsGenerator,
+		})
+	case *YieldExpression:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "YieldExpression",
+			"value": Dump(node.Value),
+		})
+	case *PipeExpression:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "PipeExpression",
+			"left":  Dump(node.Left),
+			"right": Dump(node.Right),
+		})
+	case *SpawnExpression:
+		return withPosition(node.Token, map[string]interface{}{
+			"type": "SpawnExpression",
+			"call": Dump(node.Call),
+		})
+	case *IntegerLiteral:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "IntegerLiteral",
+			"value": node.Value,
+		})
+	case *StringLiteral:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "StringLiteral",
+			"value": node.Value,
+		})
+	case *InterpolatedString:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "InterpolatedString",
+			"parts": dumpExpressions(node.Parts),
+		})
+	case *ArrayLiteral:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":     "ArrayLiteral",
+			"elements": dumpExpressions(node.Elements),
+		})
+	case *HashLiteral:
+		pairs := make([]interface{}, len(node.Keys))
+		for i, key := range node.Keys {
+			pairs[i] = map[string]interface{}{
+				"key":   Dump(key),
+				"value": Dump(node.Pairs[key]),
+			}
+		}
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "HashLiteral",
+			"pairs": pairs,
+		})
+	case *Boolean:
+		return withPosition(node.Token, map[string]interface{}{
+			"type":  "Boolean",
+			"value": node.Value,
+		})
+	case nil:
+		return nil
+	default:
+		return map[string]interface{}{"type": fmt.Sprintf("%T", node)}
+	}
+}
+
+func withPosition(tok token.Token, obj map[string]interface{}) map[string]interface{} {
+	obj["line"] = tok.Line
+	obj["column"] = tok.Column
+	return obj
+}
+
+func dumpStatements(stmts []Statement) []interface{} {
+	out := make([]interface{}, len(stmts))
+	for i, stmt := range stmts {
+		out[i] = Dump(stmt)
+	}
+	return out
+}
+
+func dumpExpressions(exps []Expression) []interface{} {
+	out := make([]interface{}, len(exps))
+	for i, exp := range exps {
+		out[i] = Dump(exp)
+	}
+	return out
+}
+
+func dumpIdentifiers(idents []*Identifier) []interface{} {
+	out := make([]interface{}, len(idents))
+	for i, ident := range idents {
+		out[i] = Dump(ident)
+	}
+	return out
+}
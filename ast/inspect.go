@@ -0,0 +1,104 @@
+// ast/inspect.go
+
+package ast
+
+import "reflect"
+
+// Inspect traverses the tree below node in source order. It calls f for each
+// node; if f returns false the children of that node are skipped. Missing
+// optional children such as the alternative of an if expression are not
+// visited.
+func Inspect(node Node, f func(Node) bool) {
+	if isNil(node) || !f(node) {
+		return
+	}
+
+	for _, child := range children(node) {
+		Inspect(child, f)
+	}
+}
+
+func children(node Node) []Node {
+	switch node := node.(type) {
+	case *Program:
+		return statements(node.Statements)
+	case *BlockStatement:
+		return statements(node.Statements)
+	case *LetStatement:
+		return []Node{node.Name, node.Value}
+	case *ReturnStatement:
+		return []Node{node.ReturnValue}
+	case *StructStatement:
+		nodes := []Node{node.Name}
+		for _, field := range node.Fields {
+			nodes = append(nodes, field)
+		}
+		return nodes
+	case *ExpressionStatement:
+		return []Node{node.Expression}
+	case *PrefixExpression:
+		return []Node{node.Right}
+	case *InfixExpression:
+		return []Node{node.Left, node.Right}
+	case *PipeExpression:
+		return []Node{node.Left, node.Right}
+	case *IfExpression:
+		return []Node{node.Condition, node.Consequence, node.Alternative}
+	case *CallExpression:
+		return append([]Node{node.Function}, expressions(node.Arguments)...)
+	case *SpawnExpression:
+		return []Node{node.Call}
+	case *YieldExpression:
+		return []Node{node.Value}
+	case *IndexExpression:
+		return []Node{node.Left, node.Index}
+	case *SliceExpression:
+		return []Node{node.Left, node.Start, node.End}
+	case *MemberExpression:
+		return []Node{node.Object, node.Property}
+	case *FunctionLiteral:
+		nodes := []Node{}
+		for _, param := range node.Parameters {
+			nodes = append(nodes, param)
+		}
+		return append(nodes, node.Body)
+	case *ArrayLiteral:
+		return expressions(node.Elements)
+	case *HashLiteral:
+		nodes := []Node{}
+		for _, key := range node.Keys {
+			nodes = append(nodes, key, node.Pairs[key])
+		}
+		return nodes
+	case *InterpolatedString:
+		return expressions(node.Parts)
+	default:
+		return nil
+	}
+}
+
+func statements(stmts []Statement) []Node {
+	nodes := make([]Node, len(stmts))
+	for i, stmt := range stmts {
+		nodes[i] = stmt
+	}
+	return nodes
+}
+
+func expressions(exps []Expression) []Node {
+	nodes := make([]Node, len(exps))
+	for i, exp := range exps {
+		nodes[i] = exp
+	}
+	return nodes
+}
+
+// isNil reports whether node is nil or a nil pointer, as left in the tree for
+// omitted parts and after parser errors.
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
@@ -0,0 +1,144 @@
+// cli/cli.go
+
+// Package cli implements the monkey command.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Exit codes returned by Run. A program that calls exit returns its code.
+const (
+	ExitOK      = 0 // success
+	ExitRuntime = 1 // the program failed with a runtime error
+	ExitUsage   = 2 // the command line was invalid
+	ExitParse   = 3 // a program has syntax errors
+	ExitIO      = 4 // a file could not be read or written
+	ExitLint    = 5 // lint found problems
+)
+
+// streams are the standard streams a command reads from and writes to.
+type streams struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+type command struct {
+	name    string
+	args    string // synopsis of the arguments
+	summary string
+	run     func(s *streams, args []string) int
+}
+
+// commands is set in init, as the commands refer back to it for their usage.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"run", "[file|-] [args...]", "run a program, passing args to it as the array args", runCommand},
+		{"repl", "", "start an interactive session", replCommand},
+		{"eval", "-e expr [args...]", "evaluate an expression and print its value", evalCommand},
+		{"check", "[files...]", "report syntax errors without running", checkCommand},
+		{"lex", "[file]", "print the tokens of a program", lexCommand},
+		{"parse", "[--json] [file]", "print the syntax tree of a program", parseCommand},
+		{"fmt", "[-w] [-d] [files...]", "format programs", fmtCommand},
+		{"lint", "[--json] [files...]", "report likely mistakes in programs", lintCommand},
+		{"lsp", "", "start a language server on standard input and output", lspCommand},
+		{"debug", "[--dap] [file [args...]]", "debug a program in the terminal, or serve a debugger client", debugCommand},
+	}
+}
+
+// Run runs the monkey command with the given arguments, excluding the program
+// name, and returns its exit code. Without arguments it starts the REPL; a
+// first argument that is not a command is run as a file.
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	s := &streams{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	if len(args) == 0 {
+		return replCommand(s, nil)
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return ExitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(s, args[1:])
+		}
+	}
+
+	if strings.HasPrefix(args[0], "-") && args[0] != "-" {
+		fmt.Fprintf(stderr, "monkey: unknown flag %s\n", args[0])
+		usage(stderr)
+		return ExitUsage
+	}
+
+	return runCommand(s, args)
+}
+
+func usage(out io.Writer) {
+	fmt.Fprintln(out, "Usage: monkey <command> [arguments]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Without a command monkey starts the REPL, or runs the file given as first argument.")
+	fmt.Fprintln(out, "Use - as a file name to read from standard input.")
+}
+
+// flags returns the flag set of the named command.
+func (s *streams) flags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(s.stderr)
+	flags.Usage = func() {
+		for _, cmd := range commands {
+			if cmd.name == name {
+				fmt.Fprintf(s.stderr, "Usage: monkey %s %s\n", cmd.name, cmd.args)
+			}
+		}
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// usageError reports a command line error of the named command.
+func (s *streams) usageError(flags *flag.FlagSet, format string, a ...interface{}) int {
+	fmt.Fprintf(s.stderr, "%s: %s\n", flags.Name(), fmt.Sprintf(format, a...))
+	flags.Usage()
+	return ExitUsage
+}
+
+// read returns the contents of the named file and the name to report it by.
+// The name - reads standard input.
+func (s *streams) read(name string) (string, string, error) {
+	if name == "-" {
+		src, err := io.ReadAll(s.stdin)
+		return "<stdin>", string(src), err
+	}
+
+	src, err := os.ReadFile(name)
+	return name, string(src), err
+}
+
+// errorf prints an error message prefixed with the name of the file.
+func (s *streams) errorf(name, format string, a ...interface{}) {
+	fmt.Fprintf(s.stderr, "%s: %s\n", name, fmt.Sprintf(format, a...))
+}
+
+// fileArgs returns the files named by args, or standard input if there are
+// none.
+func fileArgs(args []string) []string {
+	if len(args) == 0 {
+		return []string{"-"}
+	}
+	return args
+}
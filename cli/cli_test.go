@@ -0,0 +1,127 @@
+// cli/cli_test.go
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "script.mk")
+	if err := os.WriteFile(script, []byte("let x = 1 + 2;\nx"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.mk")
+	if err := os.WriteFile(broken, []byte("let x = ;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args           []string
+		stdin          string
+		expectedStdout string
+		expectedStderr string
+		expectedCode   int
+	}{
+		{[]string{"run", script}, "", "", "", ExitOK},
+		{[]string{script}, "", "", "", ExitOK},
+		{[]string{"run", "-"}, "1 + true", "", "<stdin>:1:3: type mismatch: INTEGER + BOOLEAN\n", ExitRuntime},
+		{[]string{"run", broken}, "", "", broken + ": 1:9: No prefix parse function for ; found\n", ExitParse},
+		{[]string{"run", filepath.Join(dir, "missing.mk")}, "", "", "run: open " + filepath.Join(dir, "missing.mk") + ": no such file or directory\n", ExitIO},
+		{[]string{"run"}, "", "", "run: missing file\nUsage: monkey run [file|-] [args...]\n", ExitUsage},
+		{[]string{"eval", "-e", "1 + 2"}, "", "3\n", "", ExitOK},
+		{[]string{"eval", "-e", "args", "a", "b"}, "", "[a, b]\n", "", ExitOK},
+		{[]string{"eval", "-e", "let x = 1;"}, "", "", "", ExitOK},
+		{[]string{"eval", "-e", "x"}, "", "", "<eval>:1:1: identifier not found: x\n", ExitRuntime},
+		{[]string{"eval"}, "", "", "eval: missing -e expression\nUsage: monkey eval -e expr [args...]\n  -e string\n    \tthe expression to evaluate\n", ExitUsage},
+		{[]string{"check", script, broken}, "", "", broken + ": 1:9: No prefix parse function for ; found\n", ExitParse},
+		{[]string{"check"}, "let x = 1;", "", "", ExitOK},
+		{[]string{"lex"}, "let x", "1:1\tLET\t\"let\"\n1:5\tIDENT\t\"x\"\n1:6\tEOF\t\"\"\n", "", ExitOK},
+		{[]string{"lex", "-"}, "1 | 2", "1:1\tINT\t\"1\"\n1:3\tILLEGAL\t\"|\"\n1:5\tINT\t\"2\"\n1:6\tEOF\t\"\"\n", "<stdin>: 1:3: Illegal character '|'\n", ExitParse},
+		{[]string{"parse"}, "let x=1;(1+2)*3", "let x = 1;\n((1 + 2) * 3)\n", "", ExitOK},
+		{[]string{"parse", "a", "b"}, "", "", "parse: too many files\nUsage: monkey parse [--json] [file]\n  -json\n    \tprint the tree as JSON\n", ExitUsage},
+		{[]string{"fmt"}, "let x=1", "let x = 1;\n", "", ExitOK},
+		{[]string{"fmt", "-w"}, "let x=1", "", "fmt: cannot use -w with standard input\nUsage: monkey fmt [-w] [-d] [files...]\n  -d\tprint a diff instead of the formatted program\n  -w\twrite the result to the file instead of standard output\n", ExitUsage},
+		{[]string{"lint"}, "let x = 1;", "<stdin>:1:5: unused variable x (unused)\n", "", ExitLint},
+		{[]string{"lint", "--json"}, "let len = 1; len", "[\n  {\n    \"file\": \"<stdin>\",\n    \"line\": 1,\n    \"column\": 5,\n    \"rule\": \"shadowed-builtin\",\n    \"message\": \"variable len shadows a builtin\"\n  }\n]\n", "", ExitLint},
+		{[]string{"lint", script}, "", "", "", ExitOK},
+		{[]string{"debug", script}, "n\np x\nc\n", "stopped at " + script + ":1:1 (entry)\n>    1 | let x = 1 + 2;\n(debug) stopped at " + script + ":2:1 (step)\n>    2 | x\n(debug) 3\n(debug) ", "", ExitOK},
+		{[]string{"debug", "-"}, "", "", "debug: cannot debug standard input, it is read for commands\nUsage: monkey debug [--dap] [file [args...]]\n  -dap\n    \tserve the Debug Adapter Protocol on standard input and output\n", ExitUsage},
+		{[]string{"--version"}, "", "", "monkey: unknown flag --version\n", ExitUsage},
+		{[]string{"run", "-", "-"}, "args", "", "", ExitOK},
+		{[]string{"run", "-"}, "let f = fn(x) { x + true };\nlet g = fn(x) {\n  f(x)\n};\ng(1)", "", "<stdin>:1:19: type mismatch: INTEGER + BOOLEAN\n\tat f (<stdin>:3:3)\n\tat g (<stdin>:5:1)\n", ExitRuntime},
+		{[]string{"run", "-"}, "[1] |> map(fn(x) { x[0] })", "", "<stdin>:1:21: index operator not supported: INTEGER\n", ExitRuntime},
+		{[]string{"run", "-"}, "(fn(x) { x[0] })(1)", "", "<stdin>:1:11: index operator not supported: INTEGER\n\tat <anonymous> (<stdin>:1:2)\n", ExitRuntime},
+		{[]string{"run", "-"}, "exit(3); 1 + true", "", "", 3},
+		{[]string{"run", "-"}, "let f = fn() { exit() }; f(); 1 + true", "", "", ExitOK},
+		{[]string{"eval", "-e", "exit(256)"}, "", "", "<eval>:1:1: code passed to 'exit' must be between 0 and 255, got 256\n", ExitRuntime},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := Run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+		if code != tt.expectedCode {
+			t.Errorf("%q - exit code wrong, expected %d, got %d", tt.args, tt.expectedCode, code)
+		}
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("%q - stdout wrong, expected %q, got %q", tt.args, tt.expectedStdout, stdout.String())
+		}
+		// Usage messages are long, so only their beginning is compared.
+		if stderr.String() != tt.expectedStderr && (tt.expectedCode != ExitUsage || !strings.HasPrefix(stderr.String(), tt.expectedStderr)) {
+			t.Errorf("%q - stderr wrong, expected %q, got %q", tt.args, tt.expectedStderr, stderr.String())
+		}
+	}
+}
+
+func TestFmtWrite(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.mk")
+	if err := os.WriteFile(file, []byte("let x=1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := Run([]string{"fmt", "-w", file}, nil, &stdout, &stderr); code != ExitOK {
+		t.Fatalf("exit code wrong, expected %d, got %d: %s", ExitOK, code, stderr.String())
+	}
+
+	src, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != "let x = 1;\n" {
+		t.Errorf("file not formatted, got %q", src)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := Run([]string{"parse", "--json"}, strings.NewReader(`let h = {"a": f(1)};`), &stdout, &stderr)
+	if code != ExitOK {
+		t.Fatalf("exit code wrong, expected %d, got %d: %s", ExitOK, code, stderr.String())
+	}
+
+	var tree map[string]interface{}
+	if err := json.Unmarshal(stdout.Bytes(), &tree); err != nil {
+		t.Fatalf("invalid JSON: %s", err)
+	}
+
+	let := tree["statements"].([]interface{})[0].(map[string]interface{})
+	if let["type"] != "LetStatement" || let["line"] != 1.0 || let["column"] != 1.0 {
+		t.Fatalf("wrong statement, got %v", let)
+	}
+	pair := let["value"].(map[string]interface{})["pairs"].([]interface{})[0].(map[string]interface{})
+	call := pair["value"].(map[string]interface{})
+	if call["type"] != "CallExpression" || call["column"] != 16.0 {
+		t.Errorf("wrong hash value, got %v", call)
+	}
+}
@@ -0,0 +1,44 @@
+// cli/debug.go
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/solbero/monkey/debug"
+)
+
+func debugCommand(s *streams, args []string) int {
+	flags := s.flags("debug")
+	dap := flags.Bool("dap", false, "serve the Debug Adapter Protocol on standard input and output")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if *dap {
+		if flags.NArg() != 0 {
+			return s.usageError(flags, "unexpected arguments, the client launches the program")
+		}
+		if err := debug.ServeDAP(s.stdin, s.stdout); err != nil {
+			fmt.Fprintf(s.stderr, "debug: %s\n", err)
+			return ExitRuntime
+		}
+		return ExitOK
+	}
+
+	if flags.NArg() == 0 {
+		return s.usageError(flags, "missing file")
+	}
+	if flags.Arg(0) == "-" {
+		return s.usageError(flags, "cannot debug standard input, it is read for commands")
+	}
+
+	name, src, err := s.read(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(s.stderr, "debug: %s\n", err)
+		return ExitIO
+	}
+
+	_, err = debug.Terminal(name, src, argsEnv(flags.Args()[1:]), s.stdin, s.stdout)
+	return s.exitCode(err)
+}
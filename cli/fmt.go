@@ -0,0 +1,67 @@
+// cli/fmt.go
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/solbero/monkey/exec"
+	"github.com/solbero/monkey/format"
+)
+
+func fmtCommand(s *streams, args []string) int {
+	flags := s.flags("fmt")
+	write := flags.Bool("w", false, "write the result to the file instead of standard output")
+	diff := flags.Bool("d", false, "print a diff instead of the formatted program")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	files := fileArgs(flags.Args())
+	if *write {
+		for _, file := range files {
+			if file == "-" {
+				return s.usageError(flags, "cannot use -w with standard input")
+			}
+		}
+	}
+
+	code := ExitOK
+	for _, file := range files {
+		if c := s.formatFile(file, *write, *diff); c != ExitOK {
+			code = c
+		}
+	}
+	return code
+}
+
+// formatFile formats a file and, depending on the mode, writes the result
+// back to the file, prints a diff or prints the formatted program.
+func (s *streams) formatFile(file string, write, diff bool) int {
+	name, src, err := s.read(file)
+	if err != nil {
+		fmt.Fprintln(s.stderr, err)
+		return ExitIO
+	}
+
+	res, err := format.Source(src)
+	if err != nil {
+		return s.exitCode(&exec.ParseError{Name: name, Errors: strings.Split(err.Error(), "\n")})
+	}
+
+	if diff {
+		fmt.Fprint(s.stdout, format.Diff(name, src, res))
+	}
+	if write && res != src {
+		if err := os.WriteFile(name, []byte(res), 0644); err != nil {
+			fmt.Fprintln(s.stderr, err)
+			return ExitIO
+		}
+	}
+	if !write && !diff {
+		fmt.Fprint(s.stdout, res)
+	}
+	return ExitOK
+}
@@ -0,0 +1,111 @@
+// cli/inspect.go
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/solbero/monkey/ast"
+	"github.com/solbero/monkey/exec"
+	"github.com/solbero/monkey/lexer"
+	"github.com/solbero/monkey/parser"
+	"github.com/solbero/monkey/token"
+)
+
+func checkCommand(s *streams, args []string) int {
+	flags := s.flags("check")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	code := ExitOK
+	for _, file := range fileArgs(flags.Args()) {
+		if _, c := s.parse(file); c != ExitOK {
+			code = c
+		}
+	}
+	return code
+}
+
+func lexCommand(s *streams, args []string) int {
+	flags := s.flags("lex")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if flags.NArg() > 1 {
+		return s.usageError(flags, "too many files")
+	}
+
+	name, src, err := s.read(fileArgs(flags.Args())[0])
+	if err != nil {
+		fmt.Fprintf(s.stderr, "lex: %s\n", err)
+		return ExitIO
+	}
+
+	l := lexer.New(src)
+	for {
+		tok := l.NextToken()
+		fmt.Fprintf(s.stdout, "%d:%d\t%s\t%q\n", tok.Line, tok.Column, tok.Type, tok.Literal)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+
+	for _, msg := range l.Errors() {
+		s.errorf(name, "%s", msg)
+	}
+	if len(l.Errors()) != 0 {
+		return ExitParse
+	}
+	return ExitOK
+}
+
+func parseCommand(s *streams, args []string) int {
+	flags := s.flags("parse")
+	asJSON := flags.Bool("json", false, "print the tree as JSON")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if flags.NArg() > 1 {
+		return s.usageError(flags, "too many files")
+	}
+
+	program, code := s.parse(fileArgs(flags.Args())[0])
+	if code != ExitOK {
+		return code
+	}
+
+	if *asJSON {
+		out, err := json.MarshalIndent(ast.Dump(program), "", "  ")
+		if err != nil {
+			fmt.Fprintf(s.stderr, "parse: %s\n", err)
+			return ExitIO
+		}
+		fmt.Fprintf(s.stdout, "%s\n", out)
+		return ExitOK
+	}
+
+	for _, stmt := range program.Statements {
+		fmt.Fprintln(s.stdout, stmt.String())
+	}
+	return ExitOK
+}
+
+// parse reads and parses a file, reporting any errors on stderr. The exit
+// code tells if it succeeded.
+func (s *streams) parse(file string) (*ast.Program, int) {
+	name, src, err := s.read(file)
+	if err != nil {
+		fmt.Fprintln(s.stderr, err)
+		return nil, ExitIO
+	}
+
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, s.exitCode(&exec.ParseError{Name: name, Errors: p.Errors()})
+	}
+
+	return program, ExitOK
+}
@@ -0,0 +1,58 @@
+// cli/lint.go
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/solbero/monkey/lint"
+)
+
+// fileProblem is a lint problem in the JSON output.
+type fileProblem struct {
+	File string `json:"file"`
+	lint.Problem
+}
+
+func lintCommand(s *streams, args []string) int {
+	flags := s.flags("lint")
+	asJSON := flags.Bool("json", false, "print the problems as JSON")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	code := ExitOK
+	problems := []fileProblem{}
+	for _, file := range fileArgs(flags.Args()) {
+		program, c := s.parse(file)
+		if c != ExitOK {
+			code = c
+			continue
+		}
+
+		name := file
+		if file == "-" {
+			name = "<stdin>"
+		}
+		for _, problem := range lint.Check(program) {
+			problems = append(problems, fileProblem{File: name, Problem: problem})
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(s.stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(problems)
+	} else {
+		for _, p := range problems {
+			fmt.Fprintf(s.stdout, "%s:%s\n", p.File, p.Problem)
+		}
+	}
+
+	if code == ExitOK && len(problems) > 0 {
+		code = ExitLint
+	}
+	return code
+}
@@ -0,0 +1,25 @@
+// cli/lsp.go
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/solbero/monkey/lsp"
+)
+
+func lspCommand(s *streams, args []string) int {
+	flags := s.flags("lsp")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if flags.NArg() != 0 {
+		return s.usageError(flags, "unexpected arguments")
+	}
+
+	if err := lsp.Serve(s.stdin, s.stdout); err != nil {
+		fmt.Fprintf(s.stderr, "lsp: %s\n", err)
+		return ExitRuntime
+	}
+	return ExitOK
+}
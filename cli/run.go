@@ -0,0 +1,112 @@
+// cli/run.go
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os/user"
+
+	"github.com/solbero/monkey/exec"
+	"github.com/solbero/monkey/object"
+	"github.com/solbero/monkey/repl"
+)
+
+func runCommand(s *streams, args []string) int {
+	flags := s.flags("run")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if flags.NArg() == 0 {
+		return s.usageError(flags, "missing file")
+	}
+
+	name, src, err := s.read(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(s.stderr, "run: %s\n", err)
+		return ExitIO
+	}
+
+	_, code := s.eval(name, src, flags.Args()[1:])
+	return code
+}
+
+func evalCommand(s *streams, args []string) int {
+	flags := s.flags("eval")
+	expr := flags.String("e", "", "the expression to evaluate")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if *expr == "" {
+		return s.usageError(flags, "missing -e expression")
+	}
+
+	result, code := s.eval("<eval>", *expr, flags.Args())
+	if code == ExitOK && result != nil {
+		fmt.Fprintln(s.stdout, result.Inspect())
+	}
+	return code
+}
+
+func replCommand(s *streams, args []string) int {
+	flags := s.flags("repl")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if flags.NArg() != 0 {
+		return s.usageError(flags, "unexpected arguments")
+	}
+
+	name := "there"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	fmt.Fprintf(s.stdout, "Hello %s! This is the Monkey programming language!\n", name)
+	fmt.Fprintf(s.stdout, "Feel free to type in commands\n")
+	repl.Start(s.stdin, s.stdout)
+
+	return ExitOK
+}
+
+// eval runs a program with args bound to the array args and returns its
+// value. Errors are reported on stderr.
+func (s *streams) eval(name, src string, args []string) (object.Object, int) {
+	result, err := exec.Run(name, src, argsEnv(args))
+	return result, s.exitCode(err)
+}
+
+// argsEnv returns an environment with args bound to the array args.
+func argsEnv(args []string) *object.Environment {
+	elements := make([]object.Object, len(args))
+	for i, arg := range args {
+		elements[i] = &object.String{Value: arg}
+	}
+	env := object.NewEnvironment()
+	env.Set("args", &object.Array{Elements: elements})
+	return env
+}
+
+// exitCode reports an error returned by exec and returns the exit code for
+// it.
+func (s *streams) exitCode(err error) int {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	}
+
+	fmt.Fprintln(s.stderr, err)
+
+	var parseErr *exec.ParseError
+	var runtimeErr *exec.RuntimeError
+	switch {
+	case errors.As(err, &parseErr):
+		return ExitParse
+	case errors.As(err, &runtimeErr):
+		return ExitRuntime
+	default:
+		return ExitIO
+	}
+}
@@ -0,0 +1,523 @@
+// debug/dap.go
+
+package debug
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/solbero/monkey/exec"
+	"github.com/solbero/monkey/object"
+)
+
+// threadID is the ID of the only thread the server reports; spawned tasks
+// are not shown as threads of their own.
+const threadID = 1
+
+// request is a Debug Adapter Protocol request from the client.
+type request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type response struct {
+	Seq        int         `json:"seq"`
+	Type       string      `json:"type"`
+	RequestSeq int         `json:"request_seq"`
+	Success    bool        `json:"success"`
+	Command    string      `json:"command"`
+	Message    string      `json:"message,omitempty"`
+	Body       interface{} `json:"body,omitempty"`
+}
+
+type event struct {
+	Seq   int         `json:"seq"`
+	Type  string      `json:"type"`
+	Event string      `json:"event"`
+	Body  interface{} `json:"body,omitempty"`
+}
+
+type launchArguments struct {
+	Program     string   `json:"program"`
+	Args        []string `json:"args"`
+	StopOnEntry bool     `json:"stopOnEntry"`
+}
+
+type setBreakpointsArguments struct {
+	Source struct {
+		Path string `json:"path"`
+	} `json:"source"`
+	Breakpoints []struct {
+		Line int `json:"line"`
+	} `json:"breakpoints"`
+}
+
+type breakpoint struct {
+	Verified bool `json:"verified"`
+	Line     int  `json:"line"`
+}
+
+type source struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type stackFrame struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Source source `json:"source"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+type scope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+type variable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+// dapServer is a debugging session with a client. Requests are handled in
+// the order they arrive while the program runs on its own goroutine.
+type dapServer struct {
+	mu     sync.Mutex // guards out, seq and paused
+	out    io.Writer
+	seq    int
+	paused bool
+
+	initialized bool
+	configured  bool
+	launch      *launchArguments
+	src         string
+	breakpoints []int
+	scopes      []*object.Environment // the environments of variable references
+
+	d       *Debugger // set by launch
+	started bool
+	done    chan struct{} // closed when the program has ended
+}
+
+// ServeDAP runs a debug adapter reading Debug Adapter Protocol messages from
+// in and writing to out. The client launches a program with the path of its
+// file as "program" and its arguments as "args". ServeDAP returns when the
+// client disconnects or closes in.
+func ServeDAP(in io.Reader, out io.Writer) error {
+	s := &dapServer{out: out, done: make(chan struct{})}
+	r := bufio.NewReader(in)
+
+	defer func() {
+		if s.started {
+			s.d.Terminate()
+			<-s.done
+		}
+	}()
+
+	for {
+		content, err := readMessage(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(content, &req); err != nil || req.Type != "request" {
+			continue
+		}
+
+		body, err := s.handle(&req)
+		if err := s.respond(&req, body, err); err != nil {
+			return err
+		}
+		if req.Command == "disconnect" {
+			return nil
+		}
+		if err == nil {
+			s.afterResponse(&req)
+		}
+	}
+}
+
+func (s *dapServer) handle(req *request) (interface{}, error) {
+	if !s.initialized && req.Command != "initialize" {
+		return nil, errors.New("not initialized")
+	}
+
+	switch req.Command {
+	case "initialize":
+		s.initialized = true
+		return map[string]bool{
+			"supportsConfigurationDoneRequest": true,
+			"supportsTerminateRequest":         true,
+		}, nil
+	case "launch":
+		return nil, s.launchProgram(req.Arguments)
+	case "setBreakpoints":
+		return s.setBreakpoints(req.Arguments)
+	case "configurationDone":
+		s.configured = true
+		return nil, nil
+	case "threads":
+		return map[string]interface{}{
+			"threads": []map[string]interface{}{{"id": threadID, "name": "main"}},
+		}, nil
+	case "stackTrace":
+		return s.stackTrace()
+	case "scopes":
+		return s.scopesOf(req.Arguments)
+	case "variables":
+		return s.variables(req.Arguments)
+	case "evaluate":
+		return s.evaluate(req.Arguments)
+	case "continue":
+		return map[string]bool{"allThreadsContinued": true}, s.stopped()
+	case "next", "stepIn", "stepOut":
+		return nil, s.stopped()
+	case "pause":
+		return nil, s.running()
+	case "terminate", "disconnect":
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unsupported command %s", req.Command)
+}
+
+// afterResponse carries out what a request asked for once the client has
+// been answered, so events it causes follow the response.
+func (s *dapServer) afterResponse(req *request) {
+	switch req.Command {
+	case "initialize":
+		s.event("initialized", nil)
+	case "launch", "configurationDone":
+		if s.launch != nil && s.configured && !s.started {
+			s.start()
+		}
+	case "continue":
+		s.resume((*Debugger).Continue)
+	case "next":
+		s.resume((*Debugger).StepOver)
+	case "stepIn":
+		s.resume((*Debugger).StepInto)
+	case "stepOut":
+		s.resume((*Debugger).StepOut)
+	case "pause":
+		s.d.Pause()
+	case "terminate":
+		if s.started {
+			s.d.Terminate()
+		}
+	}
+}
+
+// resume resumes the stopped program with step. The variable references of
+// the stop are no longer valid.
+func (s *dapServer) resume(step func(*Debugger)) {
+	s.mu.Lock()
+	s.paused = false
+	s.mu.Unlock()
+
+	s.scopes = nil
+	step(s.d)
+}
+
+func (s *dapServer) launchProgram(arguments json.RawMessage) error {
+	var args launchArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return err
+	}
+	if s.launch != nil {
+		return errors.New("program already launched")
+	}
+	if args.Program == "" {
+		return errors.New("missing program")
+	}
+
+	src, err := os.ReadFile(args.Program)
+	if err != nil {
+		return err
+	}
+
+	s.d = New(args.StopOnEntry)
+	s.d.SetBreakpoints(s.breakpoints)
+	s.launch, s.src = &args, string(src)
+	return nil
+}
+
+// start runs the launched program and reports its stops and its end as
+// events.
+func (s *dapServer) start() {
+	s.started = true
+
+	elements := make([]object.Object, len(s.launch.Args))
+	for i, arg := range s.launch.Args {
+		elements[i] = &object.String{Value: arg}
+	}
+	env := object.NewEnvironment()
+	env.Set("args", &object.Array{Elements: elements})
+
+	done := start(s.d, s.launch.Program, s.src, env)
+	go func() {
+		for {
+			select {
+			case stop := <-s.d.Stopped():
+				s.mu.Lock()
+				s.paused = true
+				s.mu.Unlock()
+				s.event("stopped", map[string]interface{}{
+					"reason":            stop.Reason,
+					"threadId":          threadID,
+					"allThreadsStopped": true,
+				})
+			case r := <-done:
+				s.exited(r.err)
+				close(s.done)
+				return
+			}
+		}
+	}()
+}
+
+// exited reports the end of the program with its exit code, after the error
+// it failed with if any.
+func (s *dapServer) exited(err error) {
+	code := 0
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		code = exitErr.Code
+	case err != nil:
+		code = 1
+		s.event("output", map[string]string{"category": "stderr", "output": err.Error() + "\n"})
+	}
+
+	s.event("exited", map[string]int{"exitCode": code})
+	s.event("terminated", nil)
+}
+
+func (s *dapServer) setBreakpoints(arguments json.RawMessage) (interface{}, error) {
+	var args setBreakpointsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	s.breakpoints = []int{}
+	breakpoints := []breakpoint{}
+	for _, bp := range args.Breakpoints {
+		s.breakpoints = append(s.breakpoints, bp.Line)
+		breakpoints = append(breakpoints, breakpoint{Verified: true, Line: bp.Line})
+	}
+	if s.d != nil {
+		s.d.SetBreakpoints(s.breakpoints)
+	}
+
+	return map[string]interface{}{"breakpoints": breakpoints}, nil
+}
+
+// running returns an error unless the program has been started and has not
+// ended.
+func (s *dapServer) running() error {
+	if !s.started || s.isDone() {
+		return errors.New("program is not running")
+	}
+	return nil
+}
+
+// stopped returns an error unless the program is stopped.
+func (s *dapServer) stopped() error {
+	if err := s.running(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.paused {
+		return errors.New("program is not stopped")
+	}
+	return nil
+}
+
+func (s *dapServer) isDone() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *dapServer) stackTrace() (interface{}, error) {
+	if err := s.stopped(); err != nil {
+		return nil, err
+	}
+
+	frames := []stackFrame{}
+	for i, frame := range s.d.Stack() {
+		frames = append(frames, stackFrame{
+			ID:     i + 1,
+			Name:   frame.Name,
+			Source: source{Name: filepath.Base(s.launch.Program), Path: s.launch.Program},
+			Line:   frame.Line,
+			Column: frame.Column,
+		})
+	}
+	return map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)}, nil
+}
+
+// frame returns the index in the stack of the frame with the given ID.
+func (s *dapServer) frame(id int) (int, error) {
+	if err := s.stopped(); err != nil {
+		return 0, err
+	}
+	if id < 1 || id > len(s.d.Stack()) {
+		return 0, fmt.Errorf("no frame %d", id)
+	}
+	return id - 1, nil
+}
+
+func (s *dapServer) scopesOf(arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		FrameID int `json:"frameId"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	i, err := s.frame(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	envs := Scopes(s.d.Stack()[i].Env)
+	scopes := []scope{}
+	for j, env := range envs {
+		name := "Closure"
+		switch {
+		case j == len(envs)-1:
+			name = "Globals"
+		case j == 0:
+			name = "Locals"
+		}
+
+		// Variable references index the environments handed out since the
+		// program last stopped.
+		s.scopes = append(s.scopes, env)
+		scopes = append(scopes, scope{Name: name, VariablesReference: len(s.scopes)})
+	}
+	return map[string]interface{}{"scopes": scopes}, nil
+}
+
+func (s *dapServer) variables(arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.VariablesReference < 1 || args.VariablesReference > len(s.scopes) {
+		return nil, fmt.Errorf("no variables %d", args.VariablesReference)
+	}
+
+	env := s.scopes[args.VariablesReference-1]
+	variables := []variable{}
+	for _, name := range env.Names() {
+		val, _ := env.Get(name)
+		variables = append(variables, variable{Name: name, Value: val.Inspect(), Type: string(val.Type())})
+	}
+	return map[string]interface{}{"variables": variables}, nil
+}
+
+func (s *dapServer) evaluate(arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Expression string `json:"expression"`
+		FrameID    int    `json:"frameId"`
+	}
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.FrameID == 0 {
+		args.FrameID = 1
+	}
+	i, err := s.frame(args.FrameID)
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := s.d.Evaluate(args.Expression, i)
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return map[string]interface{}{"result": "", "variablesReference": 0}, nil
+	}
+	return map[string]interface{}{"result": val.Inspect(), "type": string(val.Type()), "variablesReference": 0}, nil
+}
+
+func (s *dapServer) respond(req *request, body interface{}, err error) error {
+	resp := response{Type: "response", RequestSeq: req.Seq, Success: err == nil, Command: req.Command, Body: body}
+	if err != nil {
+		resp.Message = err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	resp.Seq = s.seq
+	return writeMessage(s.out, resp)
+}
+
+func (s *dapServer) event(name string, body interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	writeMessage(s.out, event{Seq: s.seq, Type: "event", Event: name, Body: body})
+}
+
+// readMessage reads the content of a message framed by a Content-Length
+// header.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// writeMessage writes v as JSON framed by a Content-Length header.
+func writeMessage(w io.Writer, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
@@ -0,0 +1,359 @@
+// debug/debug_test.go
+
+package debug
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/solbero/monkey/object"
+)
+
+const program = `let f = fn(n) {
+  let m = n * 2;
+  m + 1
+};
+let a = f(1);
+let b = f(a);
+a + b`
+
+// debugRun runs src under d, answering each stop with the next of steps and
+// then with Continue. It returns the stops as "reason:line" and the value of
+// the program.
+func debugRun(t *testing.T, d *Debugger, src string, steps []func(*Debugger)) ([]string, object.Object) {
+	done := start(d, "test", src, object.NewEnvironment())
+
+	stops := []string{}
+	for {
+		select {
+		case stop := <-d.Stopped():
+			stops = append(stops, fmt.Sprintf("%s:%d", stop.Reason, stop.Line))
+			if len(steps) == 0 {
+				d.Continue()
+				continue
+			}
+			steps[0](d)
+			steps = steps[1:]
+		case r := <-done:
+			if r.err != nil {
+				t.Fatalf("program failed: %s", r.err)
+			}
+			return stops, r.value
+		}
+	}
+}
+
+func repeat(step func(*Debugger), n int) []func(*Debugger) {
+	steps := make([]func(*Debugger), n)
+	for i := range steps {
+		steps[i] = step
+	}
+	return steps
+}
+
+func TestDebugger(t *testing.T) {
+	into, over, out := (*Debugger).StepInto, (*Debugger).StepOver, (*Debugger).StepOut
+
+	tests := []struct {
+		name        string
+		stopOnEntry bool
+		breakpoints []int
+		steps       []func(*Debugger)
+		expected    []string
+	}{
+		{"run", false, nil, nil, []string{}},
+		{"entry", true, nil, nil, []string{"entry:1"}},
+		{"step over", true, nil, repeat(over, 4), []string{"entry:1", "step:5", "step:6", "step:7"}},
+		{"step into", true, nil, repeat(into, 8), []string{"entry:1", "step:5", "step:2", "step:3", "step:6", "step:2", "step:3", "step:7"}},
+		{"step out", true, nil, []func(*Debugger){into, into, out, over}, []string{"entry:1", "step:5", "step:2", "step:6", "step:7"}},
+		{"breakpoints", false, []int{3, 6}, nil, []string{"breakpoint:3", "breakpoint:6", "breakpoint:3"}},
+		{"step to breakpoint", true, []int{2}, []func(*Debugger){over}, []string{"entry:1", "step:5", "breakpoint:2", "breakpoint:2"}},
+	}
+
+	for _, tt := range tests {
+		d := New(tt.stopOnEntry)
+		d.SetBreakpoints(tt.breakpoints)
+
+		stops, value := debugRun(t, d, program, tt.steps)
+		if !reflect.DeepEqual(stops, tt.expected) {
+			t.Errorf("%s: wrong stops, got %v, want %v", tt.name, stops, tt.expected)
+		}
+		if value == nil || value.Inspect() != "10" {
+			t.Errorf("%s: wrong result %v", tt.name, value)
+		}
+	}
+}
+
+func TestGeneratorStepping(t *testing.T) {
+	const src = `let gen = fn() {
+  yield 1;
+  yield 2
+};
+let it = gen();
+let a = next(it).value;
+let b = next(it).value;
+a + b`
+
+	into, over, out := (*Debugger).StepInto, (*Debugger).StepOver, (*Debugger).StepOut
+
+	tests := []struct {
+		name     string
+		steps    []func(*Debugger)
+		expected []string
+	}{
+		{"step over", repeat(over, 5), []string{"entry:1", "step:5", "step:6", "step:7", "step:8"}},
+		{"step into", repeat(into, 7), []string{"entry:1", "step:5", "step:6", "step:2", "step:7", "step:3", "step:8"}},
+		{"step out", []func(*Debugger){over, over, into, out, over}, []string{"entry:1", "step:5", "step:6", "step:2", "step:7", "step:8"}},
+	}
+
+	for _, tt := range tests {
+		stops, value := debugRun(t, New(true), src, tt.steps)
+		if !reflect.DeepEqual(stops, tt.expected) {
+			t.Errorf("%s: wrong stops, got %v, want %v", tt.name, stops, tt.expected)
+		}
+		if value == nil || value.Inspect() != "3" {
+			t.Errorf("%s: wrong result %v", tt.name, value)
+		}
+	}
+}
+
+func TestInspection(t *testing.T) {
+	d := New(false)
+	d.SetBreakpoints([]int{3})
+
+	var stack []Frame
+	var values []string
+	inspect := func(d *Debugger) {
+		stack = d.Stack()
+		scopes := Scopes(stack[0].Env)
+		if len(scopes) != 2 || !reflect.DeepEqual(scopes[0].Names(), []string{"m", "n"}) ||
+			!reflect.DeepEqual(scopes[1].Names(), []string{"a", "f"}) {
+			t.Errorf("wrong scopes %v", scopes)
+		}
+		for _, src := range []string{"m", "n + a", "b"} {
+			val, err := d.Evaluate(src, 0)
+			if err != nil {
+				t.Fatalf("evaluate %q failed: %s", src, err)
+			}
+			values = append(values, val.Inspect())
+		}
+		if val, _ := d.Evaluate("f(10)", 1); val.Inspect() != "21" {
+			t.Errorf("wrong result of a call, got %s", val.Inspect())
+		}
+		if _, err := d.Evaluate("let = 1", 0); err == nil {
+			t.Errorf("expected parse error")
+		}
+		d.StepOut()
+	}
+
+	debugRun(t, d, program, []func(*Debugger){(*Debugger).Continue, inspect})
+
+	names := []string{}
+	for _, frame := range stack {
+		names = append(names, fmt.Sprintf("%s:%d:%d", frame.Name, frame.Line, frame.Column))
+	}
+	if want := []string{"f:3:3", "<program>:6:1"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("wrong stack, got %v, want %v", names, want)
+	}
+	if want := []string{"6", "6", "ERROR: identifier not found: b"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("wrong values, got %v, want %v", values, want)
+	}
+
+}
+
+func TestTerminate(t *testing.T) {
+	d := New(true)
+	done := start(d, "test", program, object.NewEnvironment())
+
+	<-d.Stopped()
+	d.Terminate()
+
+	if r := <-done; r.value != nil || r.err != nil {
+		t.Errorf("expected program to end without value or error, got %v, %v", r.value, r.err)
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	input := "b 3\nc\nbt\np m\nenv\nbogus\nclear 3\nout\n\n"
+	var out strings.Builder
+
+	value, err := Terminal("test", program, object.NewEnvironment(), strings.NewReader(input), &out)
+	if err != nil || value == nil || value.Inspect() != "10" {
+		t.Fatalf("wrong result, got %v, %v", value, err)
+	}
+
+	expected := `stopped at test:1:1 (entry)
+>    1 | let f = fn(n) {
+(debug) breakpoint at test:3
+(debug) stopped at test:3:3 (breakpoint)
+>    3 |   m + 1
+(debug) > #0 f at test:3:3
+  #1 <program> at test:5:1
+(debug) 2
+(debug) locals:
+  let m: INTEGER = 2
+  let n: INTEGER = 1
+globals:
+  let f: FUNCTION = fn(n) { ...
+(debug) unknown command bogus, see help
+(debug) (debug) stopped at test:6:1 (step)
+>    6 | let b = f(a);
+(debug) `
+	if out.String() != expected {
+		t.Errorf("wrong output\ngot:\n%s\nwant:\n%s", out.String(), expected)
+	}
+}
+
+// dapClient talks to a debug adapter running in the same process.
+type dapClient struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Reader
+	seq    int
+	events []map[string]interface{}
+	done   chan error
+}
+
+func newDAPClient(t *testing.T) *dapClient {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &dapClient{t: t, in: inW, out: bufio.NewReader(outR), done: make(chan error, 1)}
+
+	go func() {
+		err := ServeDAP(inR, outW)
+		outW.Close()
+		c.done <- err
+	}()
+
+	return c
+}
+
+func (c *dapClient) read() map[string]interface{} {
+	content, err := readMessage(c.out)
+	if err != nil {
+		c.t.Fatalf("read failed: %s", err)
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(content, &msg); err != nil {
+		c.t.Fatalf("invalid message %s: %s", content, err)
+	}
+	return msg
+}
+
+// request sends a request and returns its response. Events read on the way
+// are kept for waitFor.
+func (c *dapClient) request(command string, arguments interface{}) map[string]interface{} {
+	c.seq++
+	err := writeMessage(c.in, map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": arguments})
+	if err != nil {
+		c.t.Fatalf("write failed: %s", err)
+	}
+
+	for {
+		msg := c.read()
+		if msg["type"] == "event" {
+			c.events = append(c.events, msg)
+			continue
+		}
+		if msg["request_seq"] != float64(c.seq) {
+			c.t.Fatalf("expected response to %s, got %v", command, msg)
+		}
+		if msg["success"] != true {
+			c.t.Fatalf("%s failed: %v", command, msg["message"])
+		}
+		body, _ := msg["body"].(map[string]interface{})
+		return body
+	}
+}
+
+// waitFor returns the body of the next event with the given name.
+func (c *dapClient) waitFor(name string) map[string]interface{} {
+	for {
+		var msg map[string]interface{}
+		if len(c.events) > 0 {
+			msg, c.events = c.events[0], c.events[1:]
+		} else {
+			msg = c.read()
+		}
+		if msg["type"] == "event" && msg["event"] == name {
+			body, _ := msg["body"].(map[string]interface{})
+			return body
+		}
+	}
+}
+
+func TestDAP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mk")
+	if err := os.WriteFile(path, []byte(program+";\nexit(len(args))"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newDAPClient(t)
+
+	caps := c.request("initialize", map[string]interface{}{"adapterID": "monkey"})
+	if caps["supportsConfigurationDoneRequest"] != true {
+		t.Errorf("unexpected capabilities %v", caps)
+	}
+	c.waitFor("initialized")
+
+	c.request("launch", map[string]interface{}{"program": path, "args": []string{"x", "y"}})
+	bps := c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]string{"path": path},
+		"breakpoints": []map[string]int{{"line": 3}},
+	})
+	if got := fmt.Sprint(bps["breakpoints"]); got != "[map[line:3 verified:true]]" {
+		t.Errorf("wrong breakpoints %s", got)
+	}
+	c.request("configurationDone", nil)
+
+	if stop := c.waitFor("stopped"); stop["reason"] != "breakpoint" {
+		t.Errorf("expected breakpoint stop, got %v", stop)
+	}
+
+	trace := c.request("stackTrace", map[string]int{"threadId": threadID})
+	frames := trace["stackFrames"].([]interface{})
+	top := frames[0].(map[string]interface{})
+	if len(frames) != 2 || top["name"] != "f" || top["line"] != float64(3) || top["id"] != float64(1) {
+		t.Errorf("wrong stack trace %v", frames)
+	}
+
+	scopes := c.request("scopes", map[string]int{"frameId": 1})["scopes"].([]interface{})
+	if len(scopes) != 2 {
+		t.Fatalf("wrong scopes %v", scopes)
+	}
+	locals := scopes[0].(map[string]interface{})
+	variables := c.request("variables", map[string]interface{}{"variablesReference": locals["variablesReference"]})
+	if got := fmt.Sprint(variables["variables"]); got != "[map[name:m type:INTEGER value:2 variablesReference:0] map[name:n type:INTEGER value:1 variablesReference:0]]" {
+		t.Errorf("wrong variables %s", got)
+	}
+
+	result := c.request("evaluate", map[string]interface{}{"expression": "m * 10", "frameId": 1})
+	if result["result"] != "20" {
+		t.Errorf("wrong evaluation %v", result)
+	}
+
+	c.request("next", map[string]int{"threadId": threadID})
+	if stop := c.waitFor("stopped"); stop["reason"] != "step" {
+		t.Errorf("expected step stop, got %v", stop)
+	}
+
+	c.request("setBreakpoints", map[string]interface{}{"source": map[string]string{"path": path}, "breakpoints": []int{}})
+	c.request("continue", map[string]int{"threadId": threadID})
+	if exited := c.waitFor("exited"); exited["exitCode"] != float64(2) {
+		t.Errorf("wrong exit code %v", exited)
+	}
+	c.waitFor("terminated")
+
+	c.request("disconnect", nil)
+	c.in.Close()
+	if err := <-c.done; err != nil {
+		t.Errorf("expected clean exit, got %s", err)
+	}
+}
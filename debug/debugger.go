@@ -0,0 +1,308 @@
+// debug/debugger.go
+
+// Package debug implements a step debugger for Monkey programs, with a
+// terminal interface and a server for the Debug Adapter Protocol.
+package debug
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/solbero/monkey/ast"
+	"github.com/solbero/monkey/evaluator"
+	"github.com/solbero/monkey/lexer"
+	"github.com/solbero/monkey/object"
+	"github.com/solbero/monkey/parser"
+)
+
+// Reasons a program stops for.
+const (
+	ReasonEntry      = "entry"
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+	ReasonPause      = "pause"
+)
+
+// Stop reports that the program stopped before the statement at Line and
+// Column.
+type Stop struct {
+	Reason       string
+	Line, Column int
+}
+
+// Frame is a function call on the stack of the program.
+type Frame struct {
+	Name         string // "<program>" for the top level
+	Line, Column int    // the position of the statement being evaluated
+	Env          *object.Environment
+}
+
+type mode int
+
+const (
+	modeRun mode = iota
+	modeStepInto
+	modeStepOver
+	modeStepOut
+)
+
+// Debugger stops a program before statements on breakpoints and when
+// stepping. It is attached to the environment the program runs in and
+// controlled from another goroutine: when the program stops, a Stop is sent
+// on Stopped and the program waits for Continue, a step or Terminate.
+//
+// While the program is stopped, its other goroutines wait at their next
+// statement. Tasks started with spawn share the call stack of the program.
+type Debugger struct {
+	mu     sync.Mutex // held by the goroutine that is stopped
+	stack  []*Frame
+	mode   mode
+	reason string // the reason to report when a step ends
+	depth  int    // the stack depth a step started at
+
+	bpMu        sync.Mutex
+	breakpoints map[int]bool
+
+	pause      atomic.Bool
+	evaluating atomic.Bool
+
+	stops  chan Stop
+	resume chan mode
+	quit   chan struct{}
+	once   sync.Once
+}
+
+// New returns a debugger that stops at the first statement if stopOnEntry is
+// set.
+func New(stopOnEntry bool) *Debugger {
+	d := &Debugger{
+		breakpoints: make(map[int]bool),
+		stops:       make(chan Stop),
+		resume:      make(chan mode),
+		quit:        make(chan struct{}),
+	}
+	if stopOnEntry {
+		d.mode, d.reason = modeStepInto, ReasonEntry
+	}
+	return d
+}
+
+// Attach makes the debugger follow programs run in env.
+func (d *Debugger) Attach(env *object.Environment) {
+	d.stack = []*Frame{{Name: "<program>", Env: env}}
+	env.SetTracer(d)
+}
+
+// Stopped returns the channel the debugger reports stops on.
+func (d *Debugger) Stopped() <-chan Stop {
+	return d.stops
+}
+
+// Statement stops the program before stmt if it should.
+func (d *Debugger) Statement(stmt ast.Statement, env *object.Environment) object.Object {
+	if d.evaluating.Load() {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	frame := d.stack[len(d.stack)-1]
+	previous := frame.Line
+	frame.Line, frame.Column = stmt.Position()
+	frame.Env = env
+
+	reason := d.stopReason(frame.Line, previous)
+	if reason == "" {
+		return d.terminated()
+	}
+
+	select {
+	case d.stops <- Stop{Reason: reason, Line: frame.Line, Column: frame.Column}:
+	case <-d.quit:
+		return d.terminated()
+	}
+
+	select {
+	case m := <-d.resume:
+		d.mode, d.reason, d.depth = m, ReasonStep, len(d.stack)
+		return nil
+	case <-d.quit:
+		return d.terminated()
+	}
+}
+
+// stopReason returns why the program should stop at a statement on line,
+// given the line of the previous statement of the same call, or "" if it
+// should not. Only the first statement of a line stops on a breakpoint.
+func (d *Debugger) stopReason(line, previous int) string {
+	switch {
+	case d.pause.Swap(false):
+		return ReasonPause
+	case d.mode == modeStepInto,
+		d.mode == modeStepOver && len(d.stack) <= d.depth,
+		d.mode == modeStepOut && len(d.stack) < d.depth:
+		return d.reason
+	case line != previous && d.hasBreakpoint(line):
+		return ReasonBreakpoint
+	}
+	return ""
+}
+
+// terminated returns the result that ends the program once Terminate has
+// been called, or nil.
+func (d *Debugger) terminated() object.Object {
+	select {
+	case <-d.quit:
+		return &object.Exit{Code: 0}
+	default:
+		return nil
+	}
+}
+
+// Call pushes a frame for fn.
+func (d *Debugger) Call(fn *object.Function, env *object.Environment) {
+	if d.evaluating.Load() {
+		return
+	}
+
+	name := fn.Name
+	if name == "" {
+		name = "<anonymous>"
+	}
+	line, column := fn.Body.Position()
+
+	d.mu.Lock()
+	d.stack = append(d.stack, &Frame{Name: name, Line: line, Column: column, Env: env})
+	d.mu.Unlock()
+}
+
+// Return pops the frame of fn.
+func (d *Debugger) Return(fn *object.Function) {
+	if d.evaluating.Load() {
+		return
+	}
+
+	d.mu.Lock()
+	if len(d.stack) > 1 {
+		d.stack = d.stack[:len(d.stack)-1]
+	}
+	d.mu.Unlock()
+}
+
+// Continue resumes the stopped program until the next breakpoint.
+func (d *Debugger) Continue() { d.step(modeRun) }
+
+// StepInto resumes the stopped program until the next statement.
+func (d *Debugger) StepInto() { d.step(modeStepInto) }
+
+// StepOver resumes the stopped program until the next statement of the
+// current call or a call it returns to.
+func (d *Debugger) StepOver() { d.step(modeStepOver) }
+
+// StepOut resumes the stopped program until the current call returns.
+func (d *Debugger) StepOut() { d.step(modeStepOut) }
+
+func (d *Debugger) step(m mode) {
+	select {
+	case d.resume <- m:
+	case <-d.quit:
+	}
+}
+
+// Pause stops the running program at its next statement.
+func (d *Debugger) Pause() {
+	d.pause.Store(true)
+}
+
+// Terminate ends the program at its next statement, or now if it is
+// stopped. The program then exits with code 0.
+func (d *Debugger) Terminate() {
+	d.once.Do(func() { close(d.quit) })
+}
+
+// SetBreakpoints replaces the breakpoints with the given lines.
+func (d *Debugger) SetBreakpoints(lines []int) {
+	d.bpMu.Lock()
+	defer d.bpMu.Unlock()
+	d.breakpoints = make(map[int]bool)
+	for _, line := range lines {
+		d.breakpoints[line] = true
+	}
+}
+
+// AddBreakpoint sets a breakpoint on line.
+func (d *Debugger) AddBreakpoint(line int) {
+	d.bpMu.Lock()
+	defer d.bpMu.Unlock()
+	d.breakpoints[line] = true
+}
+
+// RemoveBreakpoint clears the breakpoint on line and reports whether there
+// was one.
+func (d *Debugger) RemoveBreakpoint(line int) bool {
+	d.bpMu.Lock()
+	defer d.bpMu.Unlock()
+	ok := d.breakpoints[line]
+	delete(d.breakpoints, line)
+	return ok
+}
+
+// Breakpoints returns the lines with breakpoints in order.
+func (d *Debugger) Breakpoints() []int {
+	d.bpMu.Lock()
+	defer d.bpMu.Unlock()
+	lines := make([]int, 0, len(d.breakpoints))
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+func (d *Debugger) hasBreakpoint(line int) bool {
+	d.bpMu.Lock()
+	defer d.bpMu.Unlock()
+	return d.breakpoints[line]
+}
+
+// Stack returns the call stack of the stopped program, innermost call first.
+func (d *Debugger) Stack() []Frame {
+	frames := make([]Frame, len(d.stack))
+	for i, frame := range d.stack {
+		frames[len(d.stack)-1-i] = *frame
+	}
+	return frames
+}
+
+// Evaluate evaluates src in the environment of the frame at index i of Stack
+// while the program is stopped. Calls made by src are not debugged.
+func (d *Debugger) Evaluate(src string, i int) (object.Object, error) {
+	stack := d.Stack()
+	if i < 0 || i >= len(stack) {
+		return nil, errors.New("no such frame")
+	}
+
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, errors.New(strings.Join(p.Errors(), "\n"))
+	}
+
+	d.evaluating.Store(true)
+	defer d.evaluating.Store(false)
+	return evaluator.Eval(program, stack[i].Env), nil
+}
+
+// Scopes returns the environment chain of env: env itself, then the
+// environments enclosing it, ending with the global one.
+func Scopes(env *object.Environment) []*object.Environment {
+	var scopes []*object.Environment
+	for ; env != nil; env = env.Outer() {
+		scopes = append(scopes, env)
+	}
+	return scopes
+}
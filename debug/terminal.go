@@ -0,0 +1,297 @@
+// debug/terminal.go
+
+package debug
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/solbero/monkey/exec"
+	"github.com/solbero/monkey/object"
+)
+
+const PROMPT = "(debug) "
+
+// result is what a program run under the debugger ended with.
+type result struct {
+	value object.Object
+	err   error
+}
+
+// start runs the program src in env under d on a new goroutine and returns
+// the channel its result is sent on.
+func start(d *Debugger, name, src string, env *object.Environment) <-chan result {
+	d.Attach(env)
+	done := make(chan result, 1)
+	go func() {
+		value, err := exec.Run(name, src, env)
+		done <- result{value, err}
+	}()
+	return done
+}
+
+// terminal is a debugging session driven by commands read from a terminal.
+type terminal struct {
+	d     *Debugger
+	name  string
+	lines []string
+	in    *bufio.Scanner
+	out   io.Writer
+	frame int    // the index of the selected frame in the stack
+	last  string // the last command, repeated by an empty line
+}
+
+type terminalCommand struct {
+	names []string
+	args  string // synopsis of the argument
+	help  string
+	run   func(t *terminal, arg string) bool // reports whether the program resumed
+}
+
+// terminalCommands is set in init, as help refers back to it.
+var terminalCommands []terminalCommand
+
+func init() {
+	terminalCommands = []terminalCommand{
+		{[]string{"continue", "c"}, "", "run until the next breakpoint", resume((*Debugger).Continue)},
+		{[]string{"next", "n"}, "", "step over calls to the next statement", resume((*Debugger).StepOver)},
+		{[]string{"step", "s"}, "", "step into calls to the next statement", resume((*Debugger).StepInto)},
+		{[]string{"out", "o"}, "", "run until the current function returns", resume((*Debugger).StepOut)},
+		{[]string{"break", "b"}, "[line]", "set a breakpoint, or list them", (*terminal).setBreakpoint},
+		{[]string{"clear"}, "line", "clear a breakpoint", (*terminal).clearBreakpoint},
+		{[]string{"stack", "bt"}, "", "show the call stack", (*terminal).showStack},
+		{[]string{"frame", "f"}, "n", "select frame n of the call stack", (*terminal).selectFrame},
+		{[]string{"env", "e"}, "", "show the bindings of the selected frame and its enclosing scopes", (*terminal).showEnv},
+		{[]string{"print", "p"}, "expr", "evaluate an expression in the selected frame", (*terminal).print},
+		{[]string{"list", "l"}, "", "show the source around the current statement", (*terminal).list},
+		{[]string{"help", "h"}, "", "show this help", (*terminal).help},
+		{[]string{"quit", "q"}, "", "end the program and the debugger", (*terminal).quit},
+	}
+}
+
+// Terminal runs the program src in env, reading debugger commands from in
+// and writing to out. The program stops before its first statement. It
+// returns the result of the program as exec.Run does.
+func Terminal(name, src string, env *object.Environment, in io.Reader, out io.Writer) (object.Object, error) {
+	d := New(true)
+	t := &terminal{
+		d:     d,
+		name:  name,
+		lines: strings.Split(src, "\n"),
+		in:    bufio.NewScanner(in),
+		out:   out,
+	}
+
+	done := start(d, name, src, env)
+	for {
+		select {
+		case stop := <-d.Stopped():
+			t.stopped(stop)
+		case r := <-done:
+			return r.value, r.err
+		}
+	}
+}
+
+// stopped reports a stop and reads commands until one resumes the program.
+func (t *terminal) stopped(stop Stop) {
+	t.frame = 0
+	fmt.Fprintf(t.out, "stopped at %s:%d:%d (%s)\n", t.name, stop.Line, stop.Column, stop.Reason)
+	t.showLine(stop.Line, true)
+
+	for {
+		fmt.Fprint(t.out, PROMPT)
+		if !t.in.Scan() {
+			fmt.Fprintln(t.out)
+			t.d.Terminate()
+			return
+		}
+
+		line := strings.TrimSpace(t.in.Text())
+		if line == "" {
+			line = t.last
+		}
+		t.last = line
+		if line != "" && t.execute(line) {
+			return
+		}
+	}
+}
+
+// execute runs a command line and reports whether it resumed the program.
+func (t *terminal) execute(line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+
+	for _, cmd := range terminalCommands {
+		for _, n := range cmd.names {
+			if n == name {
+				return cmd.run(t, arg)
+			}
+		}
+	}
+
+	fmt.Fprintf(t.out, "unknown command %s, see help\n", name)
+	return false
+}
+
+// resume returns a command that resumes the program with step.
+func resume(step func(*Debugger)) func(*terminal, string) bool {
+	return func(t *terminal, arg string) bool {
+		step(t.d)
+		return true
+	}
+}
+
+func (t *terminal) setBreakpoint(arg string) bool {
+	if arg == "" {
+		lines := t.d.Breakpoints()
+		if len(lines) == 0 {
+			fmt.Fprintln(t.out, "no breakpoints")
+		}
+		for _, line := range lines {
+			t.showLine(line, false)
+		}
+		return false
+	}
+
+	line, ok := t.line(arg, "break")
+	if ok {
+		t.d.AddBreakpoint(line)
+		fmt.Fprintf(t.out, "breakpoint at %s:%d\n", t.name, line)
+	}
+	return false
+}
+
+func (t *terminal) clearBreakpoint(arg string) bool {
+	line, ok := t.line(arg, "clear")
+	if ok && !t.d.RemoveBreakpoint(line) {
+		fmt.Fprintf(t.out, "no breakpoint at line %d\n", line)
+	}
+	return false
+}
+
+// line parses the line number argument of a command.
+func (t *terminal) line(arg, command string) (int, bool) {
+	line, err := strconv.Atoi(arg)
+	if err != nil || line < 1 || line > len(t.lines) {
+		fmt.Fprintf(t.out, "usage: %s line, between 1 and %d\n", command, len(t.lines))
+		return 0, false
+	}
+	return line, true
+}
+
+func (t *terminal) showStack(arg string) bool {
+	for i, frame := range t.d.Stack() {
+		marker := " "
+		if i == t.frame {
+			marker = ">"
+		}
+		fmt.Fprintf(t.out, "%s #%d %s at %s:%d:%d\n", marker, i, frame.Name, t.name, frame.Line, frame.Column)
+	}
+	return false
+}
+
+func (t *terminal) selectFrame(arg string) bool {
+	stack := t.d.Stack()
+	i, err := strconv.Atoi(arg)
+	if err != nil || i < 0 || i >= len(stack) {
+		fmt.Fprintf(t.out, "usage: frame n, between 0 and %d\n", len(stack)-1)
+		return false
+	}
+
+	t.frame = i
+	fmt.Fprintf(t.out, "#%d %s at %s:%d:%d\n", i, stack[i].Name, t.name, stack[i].Line, stack[i].Column)
+	t.showLine(stack[i].Line, true)
+	return false
+}
+
+func (t *terminal) showEnv(arg string) bool {
+	scopes := Scopes(t.d.Stack()[t.frame].Env)
+	for i, env := range scopes {
+		switch {
+		case i == len(scopes)-1:
+			fmt.Fprintln(t.out, "globals:")
+		case i == 0:
+			fmt.Fprintln(t.out, "locals:")
+		default:
+			fmt.Fprintln(t.out, "closure:")
+		}
+
+		for _, name := range env.Names() {
+			val, _ := env.Get(name)
+			keyword := "let"
+			if env.IsConst(name) {
+				keyword = "const"
+			}
+			fmt.Fprintf(t.out, "  %s %s: %s = %s\n", keyword, name, val.Type(), inspect(val))
+		}
+	}
+	return false
+}
+
+// inspect returns the value of obj cut to a line of at most
+// object.MaxInspect characters.
+func inspect(obj object.Object) string {
+	return object.Summarize(obj.Inspect())
+}
+
+func (t *terminal) print(arg string) bool {
+	if arg == "" {
+		fmt.Fprintln(t.out, "usage: print expr")
+		return false
+	}
+
+	val, err := t.d.Evaluate(arg, t.frame)
+	switch {
+	case err != nil:
+		fmt.Fprintln(t.out, err)
+	case val != nil:
+		fmt.Fprintln(t.out, val.Inspect())
+	}
+	return false
+}
+
+func (t *terminal) list(arg string) bool {
+	current := t.d.Stack()[t.frame].Line
+	for line := current - 3; line <= current+3; line++ {
+		if line >= 1 && line <= len(t.lines) {
+			t.showLine(line, line == current)
+		}
+	}
+	return false
+}
+
+// showLine prints a line of the source with its number, marked with an
+// arrow if it is current.
+func (t *terminal) showLine(line int, current bool) {
+	if line < 1 || line > len(t.lines) {
+		return
+	}
+
+	marker := " "
+	if current {
+		marker = ">"
+	}
+	fmt.Fprintf(t.out, "%s %4d | %s\n", marker, line, t.lines[line-1])
+}
+
+func (t *terminal) help(arg string) bool {
+	for _, cmd := range terminalCommands {
+		usage := strings.Join(cmd.names, ", ")
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+		fmt.Fprintf(t.out, "  %-18s %s\n", usage, cmd.help)
+	}
+	fmt.Fprintln(t.out, "An empty line repeats the last command.")
+	return false
+}
+
+func (t *terminal) quit(arg string) bool {
+	t.d.Terminate()
+	return true
+}
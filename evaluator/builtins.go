@@ -3,6 +3,9 @@
 package evaluator
 
 import (
+	"sort"
+	"unicode/utf8"
+
 	"github.com/solbero/monkey/object"
 )
 
@@ -17,7 +20,9 @@ var builtins = map[string]*object.Builtin{
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(len(arg.Pairs))}
 			default:
 				return newError("argument to 'len' not supported, got %s", args[0].Type())
 			}
@@ -101,6 +106,26 @@ var builtins = map[string]*object.Builtin{
 			return &object.Array{Elements: newElements}
 		},
 	},
+	"freeze": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got %d, want 1", len(args))
+			}
+
+			object.Freeze(args[0])
+
+			return args[0]
+		},
+	},
+	"isFrozen": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got %d, want 1", len(args))
+			}
+
+			return nativeBoolToBooleanObject(object.IsFrozen(args[0]))
+		},
+	},
 	"puts": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			for _, arg := range args {
@@ -110,4 +135,98 @@ var builtins = map[string]*object.Builtin{
 			return NULL
 		},
 	},
+	"exit": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments, got %d, want 0 or 1", len(args))
+			}
+			if len(args) == 0 {
+				return &object.Exit{Code: 0}
+			}
+
+			code, ok := args[0].(*object.Integer)
+			if !ok {
+				return argumentError("exit", 0, 1, object.INTERGER_OBJ, args[0])
+			}
+			if code.Value < 0 || code.Value > 255 {
+				return newError("code passed to 'exit' must be between 0 and 255, got %d", code.Value)
+			}
+
+			return &object.Exit{Code: int(code.Value)}
+		},
+	},
+}
+
+// modules are hashes of builtins that are accessed with member syntax, e.g.
+// json.parse.
+var modules = map[string]*object.Hash{
+	"json": newModule(jsonModule),
+}
+
+func newModule(members map[string]*object.Builtin) *object.Hash {
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	module := object.NewHash()
+	for _, name := range names {
+		key := &object.String{Value: name}
+		module.Set(key.HashKey(), object.HashPair{Key: key, Value: members[name]})
+	}
+	module.Frozen.Store(true)
+
+	return module
+}
+
+func init() {
+	for _, library := range []map[string]*object.Builtin{
+		stringBuiltins,
+		arrayBuiltins,
+		hashBuiltins,
+		typeBuiltins,
+		iterBuiltins,
+		concurrencyBuiltins,
+	} {
+		for name, builtin := range library {
+			builtins[name] = builtin
+		}
+	}
+}
+
+// BuiltinNames returns the names of the builtin functions and modules in
+// sorted order.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins)+len(modules))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	for name := range modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkArgs returns an error if args does not match the wanted types exactly.
+func checkArgs(name string, args []object.Object, want ...object.ObjectType) *object.Error {
+	if len(args) != len(want) {
+		return newError("wrong number of arguments, got %d, want %d", len(args), len(want))
+	}
+
+	for i, typ := range want {
+		if args[i].Type() != typ {
+			return argumentError(name, i, len(want), typ, args[i])
+		}
+	}
+
+	return nil
+}
+
+func argumentError(name string, i, n int, want object.ObjectType, got object.Object) *object.Error {
+	if n == 1 {
+		return newError("argument to '%s' must be %s, got %s", name, want, got.Type())
+	}
+	return newError("argument %d to '%s' must be %s, got %s", i+1, name, want, got.Type())
 }
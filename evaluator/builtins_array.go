@@ -0,0 +1,523 @@
+// evaluator/builtins_array.go
+
+package evaluator
+
+import (
+	"sort"
+
+	"github.com/solbero/monkey/object"
+)
+
+var arrayBuiltins = map[string]*object.Builtin{
+	"map": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) == 2 && args[0].Type() == object.ITERATOR_OBJ {
+				it, fn, err := iteratorAndFunctionArgs("map", args, 1)
+				if err != nil {
+					return err
+				}
+				return mapIterator(it, fn)
+			}
+
+			arr, fn, err := arrayAndFunctionArgs("map", args, 1)
+			if err != nil {
+				return err
+			}
+
+			elements := make([]object.Object, len(arr.Elements))
+			for i, el := range arr.Elements {
+				result := applyFunction(fn, []object.Object{el})
+				if isError(result) {
+					return result
+				}
+				elements[i] = result
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"filter": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) == 2 && args[0].Type() == object.ITERATOR_OBJ {
+				it, fn, err := iteratorAndFunctionArgs("filter", args, 1)
+				if err != nil {
+					return err
+				}
+				return filterIterator(it, fn)
+			}
+
+			arr, fn, err := arrayAndFunctionArgs("filter", args, 1)
+			if err != nil {
+				return err
+			}
+
+			elements := []object.Object{}
+			for _, el := range arr.Elements {
+				result := applyFunction(fn, []object.Object{el})
+				if isError(result) {
+					return result
+				}
+				if isTruthy(result) {
+					elements = append(elements, el)
+				}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"reduce": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments, got %d, want 2 or 3", len(args))
+			}
+
+			var it *object.Iterator
+			var fn object.Object
+			var err *object.Error
+			if args[0].Type() == object.ITERATOR_OBJ {
+				it, fn, err = iteratorAndFunctionArgs("reduce", args[:2], 2)
+			} else {
+				var arr *object.Array
+				arr, fn, err = arrayAndFunctionArgs("reduce", args[:2], 2)
+				if err == nil {
+					it = arrayIterator(arr.Elements)
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			var acc object.Object
+			if len(args) == 3 {
+				acc = args[2]
+			} else if first, ok := it.Next(); ok {
+				acc = first
+			} else {
+				return NULL
+			}
+
+			for {
+				if isError(acc) {
+					return acc
+				}
+				el, ok := it.Next()
+				if !ok {
+					return acc
+				}
+				if isError(el) {
+					return el
+				}
+				acc = applyFunction(fn, []object.Object{acc, el})
+			}
+		},
+	},
+	"each": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) == 2 && args[0].Type() == object.ITERATOR_OBJ {
+				it, fn, err := iteratorAndFunctionArgs("each", args, 1)
+				if err != nil {
+					return err
+				}
+				for {
+					el, ok := it.Next()
+					if !ok {
+						return NULL
+					}
+					if isError(el) {
+						return el
+					}
+					if result := applyFunction(fn, []object.Object{el}); isError(result) {
+						return result
+					}
+				}
+			}
+
+			arr, fn, err := arrayAndFunctionArgs("each", args, 1)
+			if err != nil {
+				return err
+			}
+
+			for _, el := range arr.Elements {
+				result := applyFunction(fn, []object.Object{el})
+				if isError(result) {
+					return result
+				}
+			}
+
+			return NULL
+		},
+	},
+	"find": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("find", args, 1)
+			if err != nil {
+				return err
+			}
+
+			for _, el := range arr.Elements {
+				result := applyFunction(fn, []object.Object{el})
+				if isError(result) {
+					return result
+				}
+				if isTruthy(result) {
+					return el
+				}
+			}
+
+			return NULL
+		},
+	},
+	"any": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("any", args, 1)
+			if err != nil {
+				return err
+			}
+
+			for _, el := range arr.Elements {
+				result := applyFunction(fn, []object.Object{el})
+				if isError(result) {
+					return result
+				}
+				if isTruthy(result) {
+					return TRUE
+				}
+			}
+
+			return FALSE
+		},
+	},
+	"all": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("all", args, 1)
+			if err != nil {
+				return err
+			}
+
+			for _, el := range arr.Elements {
+				result := applyFunction(fn, []object.Object{el})
+				if isError(result) {
+					return result
+				}
+				if !isTruthy(result) {
+					return FALSE
+				}
+			}
+
+			return TRUE
+		},
+	},
+	"sort": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			switch len(args) {
+			case 1:
+				if err := checkArgs("sort", args, object.ARRAY_OBJ); err != nil {
+					return err
+				}
+				return sortArray(args[0].(*object.Array), compareObjects)
+			case 2:
+				arr, fn, err := arrayAndFunctionArgs("sort", args, 2)
+				if err != nil {
+					return err
+				}
+				// The comparator reports whether a sorts before b.
+				less := func(a, b object.Object) (bool, object.Object) {
+					result := applyFunction(fn, []object.Object{a, b})
+					if isError(result) {
+						return false, result
+					}
+					boolean, ok := result.(*object.Boolean)
+					if !ok {
+						return false, newError("function passed to 'sort' must return BOOLEAN, got %s", result.Type())
+					}
+					return boolean.Value, nil
+				}
+				return sortArray(arr, less)
+			default:
+				return newError("wrong number of arguments, got %d, want 1 or 2", len(args))
+			}
+		},
+	},
+	"reverse": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("reverse", args, object.ARRAY_OBJ); err != nil {
+				return err
+			}
+
+			arr := args[0].(*object.Array)
+			length := len(arr.Elements)
+
+			elements := make([]object.Object, length)
+			for i, el := range arr.Elements {
+				elements[length-1-i] = el
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"slice": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments, got %d, want 2 or 3", len(args))
+			}
+
+			want := []object.ObjectType{object.ARRAY_OBJ, object.INTERGER_OBJ, object.INTERGER_OBJ}
+			for i, arg := range args {
+				if arg.Type() != want[i] {
+					return argumentError("slice", i, len(args), want[i], arg)
+				}
+			}
+
+			arr := args[0].(*object.Array)
+			length := int64(len(arr.Elements))
+
+			start := sliceBound(args[1].(*object.Integer).Value, length)
+			end := length
+			if len(args) == 3 {
+				end = sliceBound(args[2].(*object.Integer).Value, length)
+			}
+			if end < start {
+				end = start
+			}
+
+			elements := make([]object.Object, end-start)
+			copy(elements, arr.Elements[start:end])
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"concat": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			elements := []object.Object{}
+			for i, arg := range args {
+				arr, ok := arg.(*object.Array)
+				if !ok {
+					return argumentError("concat", i, len(args), object.ARRAY_OBJ, arg)
+				}
+				elements = append(elements, arr.Elements...)
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"zip": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return newError("wrong number of arguments, got 0, want at least 1")
+			}
+
+			arrays := make([]*object.Array, len(args))
+			length := -1
+			for i, arg := range args {
+				arr, ok := arg.(*object.Array)
+				if !ok {
+					return argumentError("zip", i, len(args), object.ARRAY_OBJ, arg)
+				}
+				if length < 0 || len(arr.Elements) < length {
+					length = len(arr.Elements)
+				}
+				arrays[i] = arr
+			}
+
+			elements := make([]object.Object, length)
+			for i := range elements {
+				tuple := make([]object.Object, len(arrays))
+				for j, arr := range arrays {
+					tuple[j] = arr.Elements[i]
+				}
+				elements[i] = &object.Array{Elements: tuple}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"flatten": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("flatten", args, object.ARRAY_OBJ); err != nil {
+				return err
+			}
+
+			elements := []object.Object{}
+			for _, el := range args[0].(*object.Array).Elements {
+				if inner, ok := el.(*object.Array); ok {
+					elements = append(elements, inner.Elements...)
+				} else {
+					elements = append(elements, el)
+				}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"range": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments, got %d, want 1 to 3", len(args))
+			}
+
+			bounds := make([]int64, len(args))
+			for i, arg := range args {
+				integer, ok := arg.(*object.Integer)
+				if !ok {
+					return argumentError("range", i, len(args), object.INTERGER_OBJ, arg)
+				}
+				bounds[i] = integer.Value
+			}
+
+			var start, end, step int64 = 0, bounds[0], 1
+			if len(bounds) > 1 {
+				start, end = bounds[0], bounds[1]
+			}
+			if len(bounds) > 2 {
+				step = bounds[2]
+			}
+			if step == 0 {
+				return newError("step passed to 'range' must not be zero")
+			}
+
+			n := rangeLength(start, end, step)
+			if n > maxRangeLength {
+				return newError("range passed to 'range' is too long, got %d elements", n)
+			}
+
+			elements := make([]object.Object, n)
+			for i := range elements {
+				elements[i] = &object.Integer{Value: start + int64(i)*step}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"unique": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("unique", args, object.ARRAY_OBJ); err != nil {
+				return err
+			}
+
+			seen := make(map[object.HashKey]bool)
+			elements := []object.Object{}
+		outer:
+			for _, el := range args[0].(*object.Array).Elements {
+				key, ok := object.HashKeyOf(el)
+				if !ok {
+					for _, other := range elements {
+						if object.Equal(other, el) {
+							continue outer
+						}
+					}
+					elements = append(elements, el)
+					continue
+				}
+
+				if !seen[key] {
+					seen[key] = true
+					elements = append(elements, el)
+				}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+}
+
+// arrayAndFunctionArgs validates the (array, function) argument pair shared by
+// the higher-order builtins.
+func arrayAndFunctionArgs(name string, args []object.Object, arity int) (*object.Array, object.Object, *object.Error) {
+	if len(args) != 2 {
+		return nil, nil, newError("wrong number of arguments, got %d, want 2", len(args))
+	}
+
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, nil, argumentError(name, 0, 2, object.ARRAY_OBJ, args[0])
+	}
+
+	if err := checkCallback(name, args[1], arity); err != nil {
+		return nil, nil, err
+	}
+	return arr, args[1], nil
+}
+
+// checkCallback returns an error if fn, passed to the builtin name, is not a
+// function or takes more than the arity arguments the builtin calls it with.
+func checkCallback(name string, fn object.Object, arity int) *object.Error {
+	switch fn := fn.(type) {
+	case *object.Function:
+		if len(fn.Parameters) > arity {
+			return newError("function passed to '%s' takes %d parameters, want at most %d", name, len(fn.Parameters), arity)
+		}
+		return nil
+	case *object.Builtin:
+		return nil
+	default:
+		return argumentError(name, 1, 2, object.FUNCTION_OBJ, fn)
+	}
+}
+
+type lessFunc func(a, b object.Object) (bool, object.Object)
+
+func sortArray(arr *object.Array, less lessFunc) object.Object {
+	elements := make([]object.Object, len(arr.Elements))
+	copy(elements, arr.Elements)
+
+	var err object.Object
+	sort.SliceStable(elements, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		var result bool
+		result, err = less(elements[i], elements[j])
+		return result
+	})
+	if err != nil {
+		return err
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+// compareObjects orders integers and strings by value; mixing types or
+// sorting any other type is an error.
+func compareObjects(a, b object.Object) (bool, object.Object) {
+	switch {
+	case a.Type() == object.INTERGER_OBJ && b.Type() == object.INTERGER_OBJ:
+		return a.(*object.Integer).Value < b.(*object.Integer).Value, nil
+	case a.Type() == object.STRING_OBJ && b.Type() == object.STRING_OBJ:
+		return a.(*object.String).Value < b.(*object.String).Value, nil
+	default:
+		return false, newError("cannot compare %s and %s", a.Type(), b.Type())
+	}
+}
+
+// sliceBound resolves a possibly negative index against length and clamps it
+// to [0, length].
+func sliceBound(idx, length int64) int64 {
+	if idx < 0 {
+		idx += length
+	}
+	return clamp(idx, 0, length)
+}
+
+// maxRangeLength is the number of elements of the longest array 'range'
+// builds.
+const maxRangeLength = 1 << 26
+
+// rangeLength returns the number of elements from start to end, exclusive,
+// by step. It is computed unsigned, as end - start may overflow int64.
+func rangeLength(start, end, step int64) uint64 {
+	var distance, stride uint64
+	switch {
+	case step > 0 && end > start:
+		distance, stride = uint64(end)-uint64(start), uint64(step)
+	case step < 0 && end < start:
+		distance, stride = uint64(start)-uint64(end), -uint64(step)
+	default:
+		return 0
+	}
+
+	n := distance / stride
+	if distance%stride != 0 {
+		n++
+	}
+	return n
+}
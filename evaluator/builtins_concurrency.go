@@ -0,0 +1,176 @@
+// evaluator/builtins_concurrency.go
+
+package evaluator
+
+import (
+	"reflect"
+
+	"github.com/solbero/monkey/object"
+)
+
+// maxChannelSize is the largest buffer a channel can have, as the buffer is
+// allocated up front.
+const maxChannelSize = 1 << 20
+
+var concurrencyBuiltins = map[string]*object.Builtin{
+	"channel": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments, got %d, want 0 or 1", len(args))
+			}
+
+			size := int64(0)
+			if len(args) == 1 {
+				integer, ok := args[0].(*object.Integer)
+				if !ok {
+					return argumentError("channel", 0, 1, object.INTERGER_OBJ, args[0])
+				}
+				if integer.Value < 0 {
+					return newError("size passed to 'channel' must not be negative, got %d", integer.Value)
+				}
+				if integer.Value > maxChannelSize {
+					return newError("size passed to 'channel' must be at most %d, got %d", maxChannelSize, integer.Value)
+				}
+				size = integer.Value
+			}
+
+			return object.NewChannel(int(size))
+		},
+	},
+	"send": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got %d, want 2", len(args))
+			}
+
+			ch, ok := args[0].(*object.Channel)
+			if !ok {
+				return argumentError("send", 0, 2, object.CHANNEL_OBJ, args[0])
+			}
+
+			if !ch.Send(args[1]) {
+				return newError("send on closed channel")
+			}
+
+			return NULL
+		},
+	},
+	"recv": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("recv", args, object.CHANNEL_OBJ); err != nil {
+				return err
+			}
+
+			val, ok := args[0].(*object.Channel).Recv()
+			if !ok {
+				val = NULL
+			}
+
+			return nextResult(val, !ok)
+		},
+	},
+	"close": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("close", args, object.CHANNEL_OBJ); err != nil {
+				return err
+			}
+
+			if !args[0].(*object.Channel).Close() {
+				return newError("close of closed channel")
+			}
+
+			return NULL
+		},
+	},
+	"await": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("await", args, object.TASK_OBJ); err != nil {
+				return err
+			}
+
+			return args[0].(*object.Task).Wait()
+		},
+	},
+	"select": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got %d, want 1 or 2", len(args))
+			}
+
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return argumentError("select", 0, len(args), object.ARRAY_OBJ, args[0])
+			}
+
+			cases, err := selectCases(arr.Elements)
+			if err != nil {
+				return err
+			}
+			if len(args) == 2 {
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+			}
+
+			return selectCase(arr.Elements, cases, args)
+		},
+	},
+}
+
+// selectCases builds the cases of a select call. A channel receives from it,
+// a [channel, value] pair sends to it and a task waits for it to complete.
+func selectCases(elements []object.Object) ([]reflect.SelectCase, *object.Error) {
+	cases := make([]reflect.SelectCase, len(elements))
+	for i, el := range elements {
+		switch el := el.(type) {
+		case *object.Channel:
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(el.C)}
+		case *object.Task:
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(el.Done())}
+		case *object.Array:
+			ch, ok := pairChannel(el)
+			if !ok {
+				return nil, newError("select case %d must be CHANNEL, TASK or [CHANNEL, value]", i)
+			}
+			value := reflect.New(reflect.TypeOf((*object.Object)(nil)).Elem()).Elem()
+			value.Set(reflect.ValueOf(el.Elements[1]))
+			cases[i] = reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(ch.C), Send: value}
+		default:
+			return nil, newError("select case %d must be CHANNEL, TASK or [CHANNEL, value]", i)
+		}
+	}
+	return cases, nil
+}
+
+func pairChannel(pair *object.Array) (*object.Channel, bool) {
+	if len(pair.Elements) != 2 {
+		return nil, false
+	}
+	ch, ok := pair.Elements[0].(*object.Channel)
+	return ch, ok
+}
+
+// selectCase waits for one of the cases and returns [index, value]. The index
+// is -1 and the value is the default argument if no case was ready.
+func selectCase(elements []object.Object, cases []reflect.SelectCase, args []object.Object) (result object.Object) {
+	defer func() {
+		if recover() != nil {
+			result = newError("send on closed channel")
+		}
+	}()
+
+	chosen, recv, recvOK := reflect.Select(cases)
+	if chosen == len(elements) {
+		return &object.Array{Elements: []object.Object{&object.Integer{Value: -1}, args[1]}}
+	}
+
+	var value object.Object = NULL
+	switch el := elements[chosen].(type) {
+	case *object.Channel:
+		if recvOK {
+			value = recv.Interface().(object.Object)
+		}
+	case *object.Task:
+		value = el.Wait()
+	}
+
+	return &object.Array{Elements: []object.Object{&object.Integer{Value: int64(chosen)}, value}}
+}
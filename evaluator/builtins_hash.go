@@ -0,0 +1,121 @@
+// evaluator/builtins_hash.go
+
+package evaluator
+
+import (
+	"github.com/solbero/monkey/object"
+)
+
+var hashBuiltins = map[string]*object.Builtin{
+	"keys": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("keys", args, object.HASH_OBJ); err != nil {
+				return err
+			}
+
+			pairs := args[0].(*object.Hash).OrderedPairs()
+			elements := make([]object.Object, len(pairs))
+			for i, pair := range pairs {
+				elements[i] = pair.Key
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"values": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("values", args, object.HASH_OBJ); err != nil {
+				return err
+			}
+
+			pairs := args[0].(*object.Hash).OrderedPairs()
+			elements := make([]object.Object, len(pairs))
+			for i, pair := range pairs {
+				elements[i] = pair.Value
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"entries": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("entries", args, object.HASH_OBJ); err != nil {
+				return err
+			}
+
+			pairs := args[0].(*object.Hash).OrderedPairs()
+			elements := make([]object.Object, len(pairs))
+			for i, pair := range pairs {
+				elements[i] = &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"has": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got %d, want 2", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return argumentError("has", 0, 2, object.HASH_OBJ, args[0])
+			}
+
+			key, ok := object.HashKeyOf(args[1])
+			if !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+
+			_, ok = hash.Pairs[key]
+			return nativeBoolToBooleanObject(ok)
+		},
+	},
+	"delete": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got %d, want 2", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return argumentError("delete", 0, 2, object.HASH_OBJ, args[0])
+			}
+
+			key, ok := object.HashKeyOf(args[1])
+			if !ok {
+				return newError("unusable as hash key: %s", args[1].Type())
+			}
+
+			result := copyHash(hash)
+			result.Delete(key)
+
+			return result
+		},
+	},
+	"merge": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			result := object.NewHash()
+			for i, arg := range args {
+				hash, ok := arg.(*object.Hash)
+				if !ok {
+					return argumentError("merge", i, len(args), object.HASH_OBJ, arg)
+				}
+				for _, pair := range hash.OrderedPairs() {
+					result.Set(pair.Key.(object.Hashable).HashKey(), pair)
+				}
+			}
+
+			return result
+		},
+	},
+}
+
+func copyHash(hash *object.Hash) *object.Hash {
+	result := object.NewHash()
+	for _, pair := range hash.OrderedPairs() {
+		result.Set(pair.Key.(object.Hashable).HashKey(), pair)
+	}
+	return result
+}
@@ -0,0 +1,222 @@
+// evaluator/builtins_iter.go
+
+package evaluator
+
+import (
+	"github.com/solbero/monkey/object"
+)
+
+var iterBuiltins = map[string]*object.Builtin{
+	"iter": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got %d, want 1", len(args))
+			}
+
+			it, ok := toIterator(args[0])
+			if !ok {
+				return newError("cannot iterate over %s", args[0].Type())
+			}
+
+			return it
+		},
+	},
+	"next": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("next", args, object.ITERATOR_OBJ); err != nil {
+				return err
+			}
+
+			val, ok := args[0].(*object.Iterator).Next()
+			if isError(val) {
+				return val
+			}
+			if !ok {
+				val = NULL
+			}
+
+			return nextResult(val, !ok)
+		},
+	},
+	"take": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments, got %d, want 2", len(args))
+			}
+
+			n, ok := args[1].(*object.Integer)
+			if !ok {
+				return argumentError("take", 1, 2, object.INTERGER_OBJ, args[1])
+			}
+			if n.Value < 0 {
+				return newError("count passed to 'take' must not be negative, got %d", n.Value)
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Array:
+				end := clamp(n.Value, 0, int64(len(arg.Elements)))
+				elements := make([]object.Object, end)
+				copy(elements, arg.Elements[:end])
+				return &object.Array{Elements: elements}
+			case *object.Iterator:
+				return takeIterator(arg, n.Value)
+			default:
+				return argumentError("take", 0, 2, object.ITERATOR_OBJ, arg)
+			}
+		},
+	},
+	"collect": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("collect", args, object.ITERATOR_OBJ); err != nil {
+				return err
+			}
+
+			return collectIterator(args[0].(*object.Iterator))
+		},
+	},
+	"count": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) > 2 {
+				return newError("wrong number of arguments, got %d, want 0 to 2", len(args))
+			}
+
+			bounds := []int64{0, 1}
+			for i, arg := range args {
+				integer, ok := arg.(*object.Integer)
+				if !ok {
+					return argumentError("count", i, len(args), object.INTERGER_OBJ, arg)
+				}
+				bounds[i] = integer.Value
+			}
+
+			i, step := bounds[0], bounds[1]
+			return &object.Iterator{
+				Next: func() (object.Object, bool) {
+					val := &object.Integer{Value: i}
+					i += step
+					return val, true
+				},
+			}
+		},
+	},
+}
+
+// toIterator returns an iterator over the elements of an array, the
+// characters of a string, the entries of a hash or the iterator itself.
+func toIterator(obj object.Object) (*object.Iterator, bool) {
+	switch obj := obj.(type) {
+	case *object.Iterator:
+		return obj, true
+	case *object.Array:
+		return arrayIterator(obj.Elements), true
+	case *object.String:
+		return arrayIterator(stringChars(obj).Elements), true
+	case *object.Hash:
+		return arrayIterator(hashBuiltins["entries"].Fn(obj).(*object.Array).Elements), true
+	default:
+		return nil, false
+	}
+}
+
+func arrayIterator(elements []object.Object) *object.Iterator {
+	i := 0
+	return &object.Iterator{
+		Next: func() (object.Object, bool) {
+			if i >= len(elements) {
+				return nil, false
+			}
+			i++
+			return elements[i-1], true
+		},
+	}
+}
+
+func mapIterator(it *object.Iterator, fn object.Object) *object.Iterator {
+	return &object.Iterator{
+		Next: func() (object.Object, bool) {
+			val, ok := it.Next()
+			if !ok || isError(val) {
+				return val, ok
+			}
+			return applyFunction(fn, []object.Object{val}), true
+		},
+	}
+}
+
+func filterIterator(it *object.Iterator, fn object.Object) *object.Iterator {
+	return &object.Iterator{
+		Next: func() (object.Object, bool) {
+			for {
+				val, ok := it.Next()
+				if !ok || isError(val) {
+					return val, ok
+				}
+				result := applyFunction(fn, []object.Object{val})
+				if isError(result) {
+					return result, true
+				}
+				if isTruthy(result) {
+					return val, true
+				}
+			}
+		},
+	}
+}
+
+func takeIterator(it *object.Iterator, n int64) *object.Iterator {
+	return &object.Iterator{
+		Next: func() (object.Object, bool) {
+			if n <= 0 {
+				return nil, false
+			}
+			n--
+			return it.Next()
+		},
+	}
+}
+
+func collectIterator(it *object.Iterator) object.Object {
+	elements := []object.Object{}
+	for {
+		val, ok := it.Next()
+		if !ok {
+			return &object.Array{Elements: elements}
+		}
+		if isError(val) {
+			return val
+		}
+		elements = append(elements, val)
+	}
+}
+
+// nextResult is what next and recv return: a hash holding the value and
+// whether the iterator or channel was exhausted, in which case the value is
+// null. It tells the end apart from a null value.
+func nextResult(val object.Object, done bool) *object.Hash {
+	result := object.NewHash()
+	for _, pair := range []object.HashPair{
+		{Key: &object.String{Value: "value"}, Value: val},
+		{Key: &object.String{Value: "done"}, Value: nativeBoolToBooleanObject(done)},
+	} {
+		result.Set(pair.Key.(*object.String).HashKey(), pair)
+	}
+	return result
+}
+
+// iteratorAndFunctionArgs validates the (iterator, function) argument pair
+// of the lazy higher-order builtins.
+func iteratorAndFunctionArgs(name string, args []object.Object, arity int) (*object.Iterator, object.Object, *object.Error) {
+	if len(args) != 2 {
+		return nil, nil, newError("wrong number of arguments, got %d, want 2", len(args))
+	}
+
+	it, ok := args[0].(*object.Iterator)
+	if !ok {
+		return nil, nil, argumentError(name, 0, 2, object.ITERATOR_OBJ, args[0])
+	}
+
+	if err := checkCallback(name, args[1], arity); err != nil {
+		return nil, nil, err
+	}
+	return it, args[1], nil
+}
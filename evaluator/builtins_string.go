@@ -0,0 +1,223 @@
+// evaluator/builtins_string.go
+
+package evaluator
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/solbero/monkey/object"
+)
+
+// maxStringLength is the length in bytes of the longest string builtins
+// build, so that a huge count is an error rather than a crash.
+const maxStringLength = 1 << 30
+
+var stringBuiltins = map[string]*object.Builtin{
+	"split": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("split", args, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+				return err
+			}
+
+			str := args[0].(*object.String).Value
+			sep := args[1].(*object.String).Value
+
+			parts := strings.Split(str, sep)
+			elements := make([]object.Object, len(parts))
+			for i, part := range parts {
+				elements[i] = &object.String{Value: part}
+			}
+
+			return &object.Array{Elements: elements}
+		},
+	},
+	"join": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("join", args, object.ARRAY_OBJ, object.STRING_OBJ); err != nil {
+				return err
+			}
+
+			arr := args[0].(*object.Array)
+			sep := args[1].(*object.String).Value
+
+			parts := make([]string, len(arr.Elements))
+			for i, el := range arr.Elements {
+				str, ok := el.(*object.String)
+				if !ok {
+					return newError("element %d passed to 'join' must be STRING, got %s", i, el.Type())
+				}
+				parts[i] = str.Value
+			}
+
+			return &object.String{Value: strings.Join(parts, sep)}
+		},
+	},
+	"trim":      trimBuiltin("trim", strings.TrimSpace, strings.Trim),
+	"trimLeft":  trimBuiltin("trimLeft", trimLeftSpace, strings.TrimLeft),
+	"trimRight": trimBuiltin("trimRight", trimRightSpace, strings.TrimRight),
+	"replace": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("replace", args, object.STRING_OBJ, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+				return err
+			}
+
+			str := args[0].(*object.String).Value
+			old := args[1].(*object.String).Value
+			new := args[2].(*object.String).Value
+
+			return &object.String{Value: strings.ReplaceAll(str, old, new)}
+		},
+	},
+	"contains":   stringPredicateBuiltin("contains", strings.Contains),
+	"startsWith": stringPredicateBuiltin("startsWith", strings.HasPrefix),
+	"endsWith":   stringPredicateBuiltin("endsWith", strings.HasSuffix),
+	"indexOf": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("indexOf", args, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+				return err
+			}
+
+			str := args[0].(*object.String).Value
+			substr := args[1].(*object.String).Value
+
+			idx := strings.Index(str, substr)
+			if idx < 0 {
+				return &object.Integer{Value: -1}
+			}
+
+			return &object.Integer{Value: int64(utf8.RuneCountInString(str[:idx]))}
+		},
+	},
+	"upper": stringMapBuiltin("upper", strings.ToUpper),
+	"lower": stringMapBuiltin("lower", strings.ToLower),
+	"repeat": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("repeat", args, object.STRING_OBJ, object.INTERGER_OBJ); err != nil {
+				return err
+			}
+
+			str := args[0].(*object.String).Value
+			count := args[1].(*object.Integer).Value
+			if count < 0 {
+				return newError("count passed to 'repeat' must not be negative, got %d", count)
+			}
+			if len(str) > 0 && count > maxStringLength/int64(len(str)) {
+				return newError("count passed to 'repeat' is too large, got %d", count)
+			}
+
+			return &object.String{Value: strings.Repeat(str, int(count))}
+		},
+	},
+	"chars": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("chars", args, object.STRING_OBJ); err != nil {
+				return err
+			}
+
+			return stringChars(args[0].(*object.String))
+		},
+	},
+	"substr": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments, got %d, want 2 or 3", len(args))
+			}
+
+			want := []object.ObjectType{object.STRING_OBJ, object.INTERGER_OBJ, object.INTERGER_OBJ}
+			for i, arg := range args {
+				if arg.Type() != want[i] {
+					return argumentError("substr", i, len(args), want[i], arg)
+				}
+			}
+
+			runes := []rune(args[0].(*object.String).Value)
+			start := clamp(args[1].(*object.Integer).Value, 0, int64(len(runes)))
+			end := int64(len(runes))
+			if len(args) == 3 {
+				length := args[2].(*object.Integer).Value
+				if length < 0 {
+					return newError("length passed to 'substr' must not be negative, got %d", length)
+				}
+				if length < end-start {
+					end = start + length
+				}
+			}
+
+			return &object.String{Value: string(runes[start:end])}
+		},
+	},
+}
+
+func trimBuiltin(name string, trimSpace func(string) string, trimCutset func(string, string) string) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			switch len(args) {
+			case 1:
+				if err := checkArgs(name, args, object.STRING_OBJ); err != nil {
+					return err
+				}
+				return &object.String{Value: trimSpace(args[0].(*object.String).Value)}
+			case 2:
+				if err := checkArgs(name, args, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+					return err
+				}
+				str := args[0].(*object.String).Value
+				cutset := args[1].(*object.String).Value
+				return &object.String{Value: trimCutset(str, cutset)}
+			default:
+				return newError("wrong number of arguments, got %d, want 1 or 2", len(args))
+			}
+		},
+	}
+}
+
+func stringPredicateBuiltin(name string, predicate func(string, string) bool) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs(name, args, object.STRING_OBJ, object.STRING_OBJ); err != nil {
+				return err
+			}
+
+			str := args[0].(*object.String).Value
+			substr := args[1].(*object.String).Value
+
+			return nativeBoolToBooleanObject(predicate(str, substr))
+		},
+	}
+}
+
+func stringMapBuiltin(name string, mapping func(string) string) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs(name, args, object.STRING_OBJ); err != nil {
+				return err
+			}
+
+			return &object.String{Value: mapping(args[0].(*object.String).Value)}
+		},
+	}
+}
+
+func stringChars(str *object.String) *object.Array {
+	elements := []object.Object{}
+	for _, ch := range str.Value {
+		elements = append(elements, &object.String{Value: string(ch)})
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func trimLeftSpace(s string) string  { return strings.TrimLeftFunc(s, unicode.IsSpace) }
+func trimRightSpace(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) }
+
+func clamp(value, low, high int64) int64 {
+	if value < low {
+		return low
+	}
+	if value > high {
+		return high
+	}
+	return value
+}
@@ -0,0 +1,124 @@
+// evaluator/builtins_type.go
+
+package evaluator
+
+import (
+	"strconv"
+
+	"github.com/solbero/monkey/object"
+)
+
+var typeBuiltins = map[string]*object.Builtin{
+	"type": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got %d, want 1", len(args))
+			}
+
+			return &object.String{Value: string(args[0].Type())}
+		},
+	},
+	"isInt":      typePredicateBuiltin(object.INTERGER_OBJ),
+	"isString":   typePredicateBuiltin(object.STRING_OBJ),
+	"isBool":     typePredicateBuiltin(object.BOOLEAN_OBJ),
+	"isArray":    typePredicateBuiltin(object.ARRAY_OBJ),
+	"isHash":     typePredicateBuiltin(object.HASH_OBJ),
+	"isNull":     typePredicateBuiltin(object.NULL_OBJ),
+	"isFunction": typePredicateBuiltin(object.FUNCTION_OBJ, object.BUILTIN_OBJ),
+	"int": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got %d, want 1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.Boolean:
+				if arg.Value {
+					return &object.Integer{Value: 1}
+				}
+				return &object.Integer{Value: 0}
+			case *object.String:
+				value, err := strconv.ParseInt(arg.Value, 10, 64)
+				if err != nil {
+					return newError("could not convert %q to INTEGER", arg.Value)
+				}
+				return &object.Integer{Value: value}
+			default:
+				return newError("cannot convert %s to INTEGER", args[0].Type())
+			}
+		},
+	},
+	"str": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got %d, want 1", len(args))
+			}
+
+			if str, ok := args[0].(*object.String); ok {
+				return str
+			}
+
+			return &object.String{Value: args[0].Inspect()}
+		},
+	},
+	"bool": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got %d, want 1", len(args))
+			}
+
+			// Strings are parsed like int does, other values convert by
+			// their truthiness.
+			if str, ok := args[0].(*object.String); ok {
+				switch str.Value {
+				case "true":
+					return TRUE
+				case "false":
+					return FALSE
+				default:
+					return newError("could not convert %q to BOOLEAN", str.Value)
+				}
+			}
+
+			return nativeBoolToBooleanObject(isTruthy(args[0]))
+		},
+	},
+	"array": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got %d, want 1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Array:
+				return arg
+			case *object.String:
+				return stringChars(arg)
+			case *object.Hash:
+				return hashBuiltins["entries"].Fn(arg)
+			default:
+				return newError("cannot convert %s to ARRAY", args[0].Type())
+			}
+		},
+	},
+}
+
+func typePredicateBuiltin(types ...object.ObjectType) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments, got %d, want 1", len(args))
+			}
+
+			for _, typ := range types {
+				if args[0].Type() == typ {
+					return TRUE
+				}
+			}
+
+			return FALSE
+		},
+	}
+}
@@ -0,0 +1,49 @@
+// evaluator/constant.go
+
+package evaluator
+
+import (
+	"github.com/solbero/monkey/ast"
+	"github.com/solbero/monkey/object"
+)
+
+// Constant evaluates node if it is built from literals only, so its value is
+// known without running the program. It reports false for other expressions
+// and for those that fail, like 1 / 0.
+func Constant(node ast.Expression) (object.Object, bool) {
+	if !isConstant(node) {
+		return nil, false
+	}
+
+	val := Eval(node, object.NewEnvironment())
+	if val == nil || isError(val) {
+		return nil, false
+	}
+	return val, true
+}
+
+func isConstant(node ast.Expression) bool {
+	switch node := node.(type) {
+	case *ast.IntegerLiteral, *ast.StringLiteral, *ast.Boolean:
+		return true
+	case *ast.PrefixExpression:
+		return isConstant(node.Right)
+	case *ast.InfixExpression:
+		return isConstant(node.Left) && isConstant(node.Right)
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			if !isConstant(el) {
+				return false
+			}
+		}
+		return true
+	case *ast.HashLiteral:
+		for _, key := range node.Keys {
+			if !isConstant(key) || !isConstant(node.Pairs[key]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
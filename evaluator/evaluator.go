@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"github.com/solbero/monkey/ast"
 	"github.com/solbero/monkey/object"
+	"strings"
+	"unicode/utf8"
 )
 
 var (
@@ -15,6 +17,27 @@ var (
 )
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	if stmt, ok := node.(ast.Statement); ok {
+		if obj := trace(stmt, env); obj != nil {
+			return obj
+		}
+	}
+	return locate(eval(node, env), node)
+}
+
+// trace tells the tracer of env, if any, that stmt is about to be evaluated.
+// Blocks are not traced themselves, only the statements in them.
+func trace(stmt ast.Statement, env *object.Environment) object.Object {
+	if _, ok := stmt.(*ast.BlockStatement); ok {
+		return nil
+	}
+	if tracer, ok := env.Tracer(); ok {
+		return tracer.Statement(stmt, env)
+	}
+	return nil
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 
 	// Statements
@@ -35,7 +58,27 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		if _, ok := node.Value.(*ast.FunctionLiteral); ok {
+			val.(*object.Function).Name = node.Name.Value
+		}
+		if node.IsConst() {
+			val = env.SetConst(node.Name.Value, val)
+		} else {
+			val = env.Set(node.Name.Value, val)
+		}
+		if isError(val) {
+			return val
+		}
+
+	case *ast.StructStatement:
+		fields := make([]string, len(node.Fields))
+		for i, f := range node.Fields {
+			fields[i] = f.Value
+		}
+		definition := &object.StructType{Name: node.Name.Value, Fields: fields}
+		if val := env.Set(node.Name.Value, definition); isError(val) {
+			return val
+		}
 
 	// Identifiers
 	case *ast.Identifier:
@@ -46,6 +89,8 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return &object.Integer{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
@@ -86,14 +131,32 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return index
 		}
 		return evalIndexExpression(left, index)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
+	case *ast.MemberExpression:
+		obj := Eval(node.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		return evalMemberExpression(obj, node.Property.Value)
 
 	// Functions
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return &object.Function{Parameters: params, Body: body, Env: env, IsGenerator: node.IsGenerator}
+	case *ast.YieldExpression:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		yield, ok := env.Yield()
+		if !ok {
+			return newError("yield outside of generator")
+		}
+		return yield(val)
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
+		function := evalCallee(node.Function, env)
 		if isError(function) {
 			return function
 		}
@@ -101,7 +164,11 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		return locate(callFunction(function, args, node.Function), node.Function)
+	case *ast.SpawnExpression:
+		return evalSpawnExpression(node, env)
+	case *ast.PipeExpression:
+		return evalPipeExpression(node, env)
 	}
 
 	return nil
@@ -111,6 +178,29 @@ func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// locate gives an error without a position the position of node, the
+// innermost node it was returned from.
+func locate(obj object.Object, node ast.Node) object.Object {
+	if err, ok := obj.(*object.Error); ok && err.Line == 0 && err != errGeneratorAborted {
+		err.Line, err.Column = node.Position()
+	}
+	return obj
+}
+
+// callFunction applies fn to args. If it fails inside the body of a function,
+// the call at callee is added to the trace of the error.
+func callFunction(fn object.Object, args []object.Object, callee ast.Node) object.Object {
+	result := applyFunction(fn, args)
+
+	function, ok := fn.(*object.Function)
+	if err, isErr := result.(*object.Error); ok && isErr && err.Line != 0 {
+		line, column := callee.Position()
+		err.Trace = append(err.Trace, object.Frame{Function: function.Name, Line: line, Column: column})
+	}
+
+	return result
+}
+
 func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object {
 	var result object.Object
 
@@ -120,7 +210,7 @@ func evalProgram(stmts []ast.Statement, env *object.Environment) object.Object {
 		switch result := result.(type) {
 		case *object.ReturnValue:
 			return result.Value
-		case *object.Error:
+		case *object.Error, *object.Exit:
 			return result
 		}
 	}
@@ -136,7 +226,7 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 
 		if result != nil {
 			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ || rt == object.EXIT_OBJ {
 				return result
 			}
 		}
@@ -154,33 +244,36 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return builtin
 	}
 
+	if module, ok := modules[node.Value]; ok {
+		return module
+	}
+
 	return newError("identifier not found: %s", node.Value)
 }
 
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
+	for _, keyNode := range node.Keys {
 		key := Eval(keyNode, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.HashKeyOf(key)
 		if !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(node.Pairs[keyNode], env)
 		if isError(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashKey, object.HashPair{Key: key, Value: value})
 	}
 
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
@@ -238,9 +331,9 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(object.Equal(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
@@ -260,6 +353,9 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case "*":
 		return &object.Integer{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
 		return &object.Integer{Value: leftVal / rightVal}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
@@ -317,6 +413,8 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTERGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTERGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
@@ -329,6 +427,10 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	idx := index.(*object.Integer).Value
 	max := int64(len(arrayObject.Elements) - 1)
 
+	if idx < 0 {
+		idx += max + 1
+	}
+
 	if idx < 0 || idx > max {
 		return NULL
 	}
@@ -336,16 +438,90 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	return arrayObject.Elements[idx]
 }
 
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	runes := []rune(str.(*object.String).Value)
+	idx := index.(*object.Integer).Value
+	max := int64(len(runes) - 1)
+
+	if idx < 0 {
+		idx += max + 1
+	}
+
+	if idx < 0 || idx > max {
+		return NULL
+	}
+
+	return &object.String{Value: string(runes[idx])}
+}
+
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	var length int64
+	switch left := left.(type) {
+	case *object.Array:
+		length = int64(len(left.Elements))
+	case *object.String:
+		length = int64(utf8.RuneCountInString(left.Value))
+	default:
+		return newError("slice operator not supported: %s", left.Type())
+	}
+
+	start, err := evalSliceBound(node.Start, env, 0, length)
+	if err != nil {
+		return err
+	}
+	end, err := evalSliceBound(node.End, env, length, length)
+	if err != nil {
+		return err
+	}
+
+	if end < start {
+		end = start
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		elements := make([]object.Object, end-start)
+		copy(elements, left.Elements[start:end])
+		return &object.Array{Elements: elements}
+	default:
+		runes := []rune(left.(*object.String).Value)
+		return &object.String{Value: string(runes[start:end])}
+	}
+}
+
+func evalSliceBound(node ast.Expression, env *object.Environment, omitted, length int64) (int64, object.Object) {
+	if node == nil {
+		return omitted, nil
+	}
+
+	evaluated := Eval(node, env)
+	if isError(evaluated) {
+		return 0, evaluated
+	}
+
+	integer, ok := evaluated.(*object.Integer)
+	if !ok {
+		return 0, newError("slice bound must be INTEGER, got %s", evaluated.Type())
+	}
+
+	return sliceBound(integer.Value, length), nil
+}
+
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
 
-	key, ok := index.(object.Hashable)
+	key, ok := object.HashKeyOf(index)
 
 	if !ok {
 		return newError("unusable as hash key: %s", index.Type())
 	}
 
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	pair, ok := hashObject.Pairs[key]
 
 	if !ok {
 		return NULL
@@ -354,19 +530,147 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 	return pair.Value
 }
 
+func evalMemberExpression(obj object.Object, name string) object.Object {
+	switch obj := obj.(type) {
+	case *object.Hash:
+		// A key takes precedence over a method of the same name, as in
+		// evalMethod.
+		if _, ok := obj.Pairs[(&object.String{Value: name}).HashKey()]; !ok {
+			if method, ok := findMethod(obj, name); ok {
+				return bindMethod(method, obj)
+			}
+		}
+		return evalHashIndexExpression(obj, &object.String{Value: name})
+	case *object.Struct:
+		idx := obj.Definition.FieldIndex(name)
+		if idx < 0 {
+			if method, ok := findMethod(obj, name); ok {
+				return bindMethod(method, obj)
+			}
+			return newError("unknown field %s on struct %s", name, obj.Definition.Name)
+		}
+		return obj.Values[idx]
+	default:
+		if method, ok := findMethod(obj, name); ok {
+			return bindMethod(method, obj)
+		}
+		return newError("member access not supported: %s", obj.Type())
+	}
+}
+
+// evalCallee evaluates the function part of a call expression, resolving
+// receiver.name(...) through evalMethod.
+func evalCallee(node ast.Expression, env *object.Environment) object.Object {
+	if member, ok := node.(*ast.MemberExpression); ok {
+		return evalMethod(member, env)
+	}
+	return Eval(node, env)
+}
+
+// evalInterpolatedString concatenates the parts of the string. Values other
+// than strings are converted like the str builtin does.
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+
+	for _, part := range node.Parts {
+		val := Eval(part, env)
+		if isError(val) {
+			return val
+		}
+		if str, ok := val.(*object.String); ok {
+			out.WriteString(str.Value)
+		} else {
+			out.WriteString(val.Inspect())
+		}
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+func evalPipeExpression(node *ast.PipeExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	call, ok := node.Right.(*ast.CallExpression)
+	if !ok {
+		function := evalCallee(node.Right, env)
+		if isError(function) {
+			return function
+		}
+		return callFunction(function, []object.Object{left}, node.Right)
+	}
+
+	function := evalCallee(call.Function, env)
+	if isError(function) {
+		return function
+	}
+	args := evalExpressions(call.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+
+	return callFunction(function, append([]object.Object{left}, args...), call.Function)
+}
+
+// evalSpawnExpression evaluates the callee and arguments of the call before
+// running it on a new goroutine.
+func evalSpawnExpression(node *ast.SpawnExpression, env *object.Environment) object.Object {
+	function := evalCallee(node.Call.Function, env)
+	if isError(function) {
+		return function
+	}
+	args := evalExpressions(node.Call.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return args[0]
+	}
+
+	task := object.NewTask()
+	go func() {
+		task.Complete(locate(callFunction(function, args, node.Call.Function), node.Call.Function))
+	}()
+
+	return task
+}
+
 func applyFunction(fn object.Object, args []object.Object) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
+		// Extra arguments are ignored, missing ones would leave parameters
+		// unbound.
+		if len(args) < len(fn.Parameters) {
+			return newError("wrong number of arguments, got %d, want %d", len(args), len(fn.Parameters))
+		}
 		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
+		if fn.IsGenerator {
+			return newGenerator(fn, extendedEnv)
+		}
+		return evalBody(fn, extendedEnv)
 	case *object.Builtin:
 		return fn.Fn(args...)
+	case *object.StructType:
+		if len(args) != len(fn.Fields) {
+			return newError("wrong number of fields for struct %s, got %d, want %d", fn.Name, len(args), len(fn.Fields))
+		}
+		values := make([]object.Object, len(args))
+		copy(values, args)
+		return &object.Struct{Definition: fn, Values: values}
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 }
 
+// evalBody evaluates the body of fn in env, telling the tracer of env, if
+// any, about the call.
+func evalBody(fn *object.Function, env *object.Environment) object.Object {
+	if tracer, ok := env.Tracer(); ok {
+		tracer.Call(fn, env)
+		defer tracer.Return(fn)
+	}
+	return unwrapReturnValue(Eval(fn.Body, env))
+}
+
 func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
 	env := object.NewEnclosedEnvironment(fn.Env)
 
@@ -384,9 +688,11 @@ func unwrapReturnValue(obj object.Object) object.Object {
 	return obj
 }
 
+// isError reports whether obj is an error or an exit, both of which unwind
+// evaluation.
 func isError(obj object.Object) bool {
 	if obj != nil {
-		return obj.Type() == object.ERROR_OBJ
+		return obj.Type() == object.ERROR_OBJ || obj.Type() == object.EXIT_OBJ
 	}
 	return false
 }
@@ -3,6 +3,7 @@
 package evaluator
 
 import (
+	"github.com/solbero/monkey/ast"
 	"github.com/solbero/monkey/lexer"
 	"github.com/solbero/monkey/object"
 	"github.com/solbero/monkey/parser"
@@ -18,6 +19,11 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"10", 10},
 		{"-5", -5},
 		{"-10", -10},
+		{"0xff", 255},
+		{"0o17", 15},
+		{"0b101", 5},
+		{"1_000_000", 1000000},
+		{"0x7FFF_FFFF_FFFF_FFFF", 9223372036854775807},
 		{"5 + 5 + 5 + 5 - 10", 10},
 		{"2 * 2 * 2 * 2 * 2", 32},
 		{"-50 + 100 + -50", 0},
@@ -32,7 +38,7 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		checkIntegerObject(t, evaluated, tt.expected)
 	}
 }
@@ -52,7 +58,7 @@ func TestIfElseExpression(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			checkIntegerObject(t, evaluated, int64(integer))
@@ -90,10 +96,31 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{`"hello" == "goodbye"`, false},
 		{`"hello" != "hello"`, false},
 		{`"hello" != "goodbye"`, true},
+		{`[1, 2] == [1, 2]`, true},
+		{`[1, 2] != [1, 2]`, false},
+		{`[1, 2] == [2, 1]`, false},
+		{`[1, 2] == [1, 2, 3]`, false},
+		{`[] == []`, true},
+		{`[1, [2, "a"]] == [1, [2, "a"]]`, true},
+		{`[1, [2, "a"]] == [1, [2, "b"]]`, false},
+		{`{"a": 1} == {"a": 1}`, true},
+		{`{"a": 1, "b": 2} == {"b": 2, "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`{"a": [1, {"b": true}]} == {"a": [1, {"b": true}]}`, true},
+		{`{[1, 2]: "x"} == {[1, 2]: "x"}`, true},
+		{`[1] == {"a": 1}`, false},
+		{`1 == "1"`, false},
+		{`first([]) == first([])`, true},
+		{`let f = fn(x) { x }; f == f`, true},
+		{`fn(x) { x } == fn(x) { x }`, false},
+		{`let mk = fn() { fn(x) { x } }; mk() == mk()`, false},
+		{`len == len`, true},
+		{`len == first`, false},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		checkBooleanObject(t, evaluated, tt.expected)
 	}
 }
@@ -112,7 +139,7 @@ func TestBangOperator(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		checkBooleanObject(t, evaluated, tt.expected)
 	}
 }
@@ -132,7 +159,7 @@ func TestReturnStatements(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		checkIntegerObject(t, evaluated, tt.expected)
 	}
 }
@@ -149,13 +176,52 @@ func TestLetStatements(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		checkIntegerObject(t, testEval(tt.input), tt.expected)
+		checkIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestConstStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"const a = 5; a;", 5},
+		{"const a = 5; let f = fn() { let a = 10; a }; f();", 10},
+		{"const a = 5; let f = fn(a) { a }; f(7);", 7},
+		{"let a = 1; const a = 2; a;", 2},
+	}
+
+	for _, tt := range tests {
+		checkIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"isFrozen([1])", false},
+		{"isFrozen(freeze([1]))", true},
+		{"let a = [[1], {\"b\": [2]}]; freeze(a); isFrozen(a[0])", true},
+		{"let a = [[1], {\"b\": [2]}]; freeze(a); isFrozen(a[1].b)", true},
+		{"let a = [1]; let b = [a]; freeze(a); isFrozen(b)", false},
+		{"isFrozen(1)", true},
+		{"freeze([1, 2]) == [1, 2]", true},
+		{"isFrozen(json)", true},
+		{"struct P { a }; isFrozen(P([1]))", false},
+		{"struct P { a }; let p = freeze(P([1])); [isFrozen(p), isFrozen(p.a)] == [true, true]", true},
+		{"struct P { a }; let a = [freeze(P({}))]; isFrozen(a[0].a)", true},
+	}
+
+	for _, tt := range tests {
+		checkBooleanObject(t, testEval(t, tt.input), tt.expected)
 	}
 }
 
 func TestFunctionObject(t *testing.T) {
 	input := "fn(x) { x + 2; };"
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 
 	fn, ok := evaluated.(*object.Function)
 	if !ok {
@@ -187,10 +253,11 @@ func TestFunctionApplication(t *testing.T) {
 		{"let add = fn(x, y) { x + y; }; add(5, 5);", 10},
 		{"let add = fn(x, y) { x + y; }; add(5 + 5, add(5, 5));", 20}, // nested function calls
 		{"fn(x) { x; }(5)", 5},                                        // immediately invoked function expression
+		{"fn(x) { x; }(5, 6)", 5},                                     // extra arguments are ignored
 	}
 
 	for _, tt := range tests {
-		checkIntegerObject(t, testEval(tt.input), tt.expected)
+		checkIntegerObject(t, testEval(t, tt.input), tt.expected)
 	}
 }
 
@@ -203,7 +270,7 @@ func TestClosures(t *testing.T) {
 	let addTwo = newAdder(2);
 	addTwo(2);
 	`
-	checkIntegerObject(t, testEval(input), 4)
+	checkIntegerObject(t, testEval(t, input), 4)
 }
 
 func TestStringLiteral(t *testing.T) {
@@ -216,12 +283,13 @@ func TestStringLiteral(t *testing.T) {
 		{input: `"hello\nworld"`, expected: "hello\nworld"},
 		{input: `"hello\t\t\tworld"`, expected: "hello\t\t\tworld"},
 		{input: `"hello\\world"`, expected: "hello\\world"},
-		{input: `"hello\bworld"`, expected: "helloworld"},
+		{input: `"\x41\u{e9}\u{1F600}\$"`, expected: "A\u00e9\U0001F600$"},
+		{input: "`raw\\n\n`", expected: "raw\\n\n"},
 		{input: `"Hello" + " " + "World!"`, expected: "Hello World!"},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 
 		str, ok := evaluated.(*object.String)
 		if !ok {
@@ -234,6 +302,28 @@ func TestStringLiteral(t *testing.T) {
 	}
 }
 
+func TestInterpolatedStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let name = "Monkey"; "hello ${name}!"`, "hello Monkey!"},
+		{`"${1 + 2} and ${true}"`, "3 and true"},
+		{`"list: ${[1, 2]}"`, "list: [1, 2]"},
+		{`let h = {"a": 1}; "${h["a"]}"`, "1"},
+		{`"outer ${"inner ${1}"}"`, "outer inner 1"},
+		{`"\${not} ${"interpolated"}"`, "${not} interpolated"},
+		{`let greet = x => "hi ${x}"; greet("you")`, "hi you"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		checkStringObject(t, evaluated, tt.expected)
+	}
+
+	checkErrorObject(t, testEval(t, `"${1 + true}"`), "type mismatch: INTEGER + BOOLEAN")
+}
+
 func TestBuiltinFunctions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -242,6 +332,7 @@ func TestBuiltinFunctions(t *testing.T) {
 		{input: `len("")`, expected: 0},
 		{input: `len("four")`, expected: 4},
 		{input: `len("hello world")`, expected: 11},
+		{input: `len("héllo wörld")`, expected: 11},
 		{input: `len(1)`, expected: "argument to 'len' not supported, got INTEGER"},
 		{input: `len("one", "two")`, expected: "wrong number of arguments, got 2, want 1"},
 		{input: `first([1, 2, 3])`, expected: 1},
@@ -262,7 +353,7 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 
 		switch expected := tt.expected.(type) {
 		case int:
@@ -280,10 +371,284 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: `split("a,b,c", ",")`, expected: []string{"a", "b", "c"}},
+		{input: `split("abc", "")`, expected: []string{"a", "b", "c"}},
+		{input: `join(["a", "b", "c"], "-")`, expected: "a-b-c"},
+		{input: `join([], "-")`, expected: ""},
+		{input: `trim("  hello  ")`, expected: "hello"},
+		{input: `trim("xxhelloxx", "x")`, expected: "hello"},
+		{input: `trimLeft("  hello  ")`, expected: "hello  "},
+		{input: `trimRight("  hello  ")`, expected: "  hello"},
+		{input: `replace("foo bar foo", "foo", "baz")`, expected: "baz bar baz"},
+		{input: `contains("hello world", "o w")`, expected: true},
+		{input: `contains("hello world", "x")`, expected: false},
+		{input: `startsWith("hello", "he")`, expected: true},
+		{input: `startsWith("hello", "lo")`, expected: false},
+		{input: `endsWith("hello", "lo")`, expected: true},
+		{input: `indexOf("hello", "l")`, expected: 2},
+		{input: `indexOf("héllo", "l")`, expected: 2},
+		{input: `indexOf("hello", "x")`, expected: -1},
+		{input: `upper("Hello")`, expected: "HELLO"},
+		{input: `lower("Hello")`, expected: "hello"},
+		{input: `repeat("ab", 3)`, expected: "ababab"},
+		{input: `chars("hé!")`, expected: []string{"h", "é", "!"}},
+		{input: `substr("héllo", 1)`, expected: "éllo"},
+		{input: `substr("héllo", 1, 3)`, expected: "éll"},
+		{input: `substr("hello", 3, 10)`, expected: "lo"},
+		{input: `substr("hello", 10)`, expected: ""},
+		{input: `substr("hello", 1, 9223372036854775807)`, expected: "ello"},
+		{input: `repeat("", 9223372036854775807)`, expected: ""},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			checkIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			checkBooleanObject(t, evaluated, expected)
+		case string:
+			checkStringObject(t, evaluated, expected)
+		case []string:
+			array, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("object is not Array, got %T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if len(array.Elements) != len(expected) {
+				t.Errorf("wrong num of elements, want %d, got %d", len(expected), len(array.Elements))
+				continue
+			}
+			for i, el := range expected {
+				checkStringObject(t, array.Elements[i], el)
+			}
+		}
+	}
+}
+
+func TestStringBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedMsg string
+	}{
+		{`split("abc")`, "wrong number of arguments, got 1, want 2"},
+		{`split(1, ",")`, "argument 1 to 'split' must be STRING, got INTEGER"},
+		{`join(["a", 1], ",")`, "element 1 passed to 'join' must be STRING, got INTEGER"},
+		{`trim("a", "b", "c")`, "wrong number of arguments, got 3, want 1 or 2"},
+		{`upper(1)`, "argument to 'upper' must be STRING, got INTEGER"},
+		{`repeat("a", -1)`, "count passed to 'repeat' must not be negative, got -1"},
+		{`repeat("ab", 9223372036854775807)`, "count passed to 'repeat' is too large, got 9223372036854775807"},
+		{`substr("abc", "1")`, "argument 2 to 'substr' must be INTEGER, got STRING"},
+		{`substr("abc", 1, -1)`, "length passed to 'substr' must not be negative, got -1"},
+	}
+
+	for _, tt := range tests {
+		checkErrorObject(t, testEval(t, tt.input), tt.expectedMsg)
+	}
+}
+
+func TestArrayBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: `map([1, 2, 3], fn(x) { x * 2 })`, expected: []int64{2, 4, 6}},
+		{input: `map([], fn(x) { x * 2 })`, expected: []int64{}},
+		{input: `map(["a", "bc"], len)`, expected: []int64{1, 2}},
+		{input: `filter([1, 2, 3, 4], fn(x) { x > 2 })`, expected: []int64{3, 4}},
+		{input: `reduce([1, 2, 3, 4], fn(acc, x) { acc + x }, 10)`, expected: 20},
+		{input: `reduce([1, 2, 3, 4], fn(acc, x) { acc * x })`, expected: 24},
+		{input: `reduce([], fn(acc, x) { acc + x })`, expected: nil},
+		{input: `each([1, 2], fn(x) { x })`, expected: nil},
+		{input: `find([1, 2, 3], fn(x) { x > 1 })`, expected: 2},
+		{input: `find([1, 2, 3], fn(x) { x > 3 })`, expected: nil},
+		{input: `any([1, 2, 3], fn(x) { x > 2 })`, expected: true},
+		{input: `any([], fn(x) { true })`, expected: false},
+		{input: `all([1, 2, 3], fn(x) { x > 0 })`, expected: true},
+		{input: `all([1, 2, 3], fn(x) { x > 1 })`, expected: false},
+		{input: `sort([3, 1, 2])`, expected: []int64{1, 2, 3}},
+		{input: `sort([3, 1, 2], fn(a, b) { a > b })`, expected: []int64{3, 2, 1}},
+		{input: `map(sort(["b", "c", "a"]), fn(s) { indexOf("abc", s) })`, expected: []int64{0, 1, 2}},
+		{input: `reverse([1, 2, 3])`, expected: []int64{3, 2, 1}},
+		{input: `slice([1, 2, 3, 4], 1, 3)`, expected: []int64{2, 3}},
+		{input: `slice([1, 2, 3, 4], 2)`, expected: []int64{3, 4}},
+		{input: `slice([1, 2, 3, 4], -2)`, expected: []int64{3, 4}},
+		{input: `slice([1, 2, 3, 4], 3, 1)`, expected: []int64{}},
+		{input: `concat([1], [], [2, 3])`, expected: []int64{1, 2, 3}},
+		{input: `map(zip([1, 2, 3], [10, 20]), fn(p) { p[0] + p[1] })`, expected: []int64{11, 22}},
+		{input: `flatten([1, [2, 3], [], [4]])`, expected: []int64{1, 2, 3, 4}},
+		{input: `range(3)`, expected: []int64{0, 1, 2}},
+		{input: `range(2, 5)`, expected: []int64{2, 3, 4}},
+		{input: `range(5, 0, -2)`, expected: []int64{5, 3, 1}},
+		{input: `range(0, 10, 9223372036854775807)`, expected: []int64{0}},
+		{input: `range(-9223372036854775807, 9223372036854775807, 9223372036854775807)`, expected: []int64{-9223372036854775807, 0}},
+		{input: `range(5, 0)`, expected: []int64{}},
+		{input: `map([1, 2], fn() { 0 })`, expected: []int64{0, 0}},
+		{input: `unique([1, 2, 1, 3, 2])`, expected: []int64{1, 2, 3}},
+		{input: `len(unique(["a", "b", "a"]))`, expected: 2},
+		{input: `len(unique([[1, 2], [1, 2], [2]]))`, expected: 2},
+		{input: `let f = fn() {}; len(unique([[f], [f], [len]]))`, expected: 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			checkIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			checkBooleanObject(t, evaluated, expected)
+		case []int64:
+			checkIntegerArray(t, evaluated, expected)
+		default:
+			checkNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestArrayBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedMsg string
+	}{
+		{`map([1])`, "wrong number of arguments, got 1, want 2"},
+		{`map(1, fn(x) { x })`, "argument 1 to 'map' must be ARRAY, got INTEGER"},
+		{`map([1], 1)`, "argument 2 to 'map' must be FUNCTION, got INTEGER"},
+		{`map([1, true], fn(x) { x + 1 })`, "type mismatch: BOOLEAN + INTEGER"},
+		{`map([1], fn(x, y) { x })`, "function passed to 'map' takes 2 parameters, want at most 1"},
+		{`reduce([1], fn(a, b, c) { a }, 0)`, "function passed to 'reduce' takes 3 parameters, want at most 2"},
+		{`sort([1, "a"])`, "cannot compare STRING and INTEGER"},
+		{`sort([2, 1], fn(a, b) { a + true })`, "type mismatch: INTEGER + BOOLEAN"},
+		{`sort([3, 1, 2], fn(a, b) { a - b })`, "function passed to 'sort' must return BOOLEAN, got INTEGER"},
+		{`concat([1], 2)`, "argument 2 to 'concat' must be ARRAY, got INTEGER"},
+		{`range(0, 10, 0)`, "step passed to 'range' must not be zero"},
+		{`range(-9223372036854775807, 9223372036854775807)`, "range passed to 'range' is too long, got 18446744073709551614 elements"},
+	}
+
+	for _, tt := range tests {
+		checkErrorObject(t, testEval(t, tt.input), tt.expectedMsg)
+	}
+}
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`keys({"b": 1, "a": 2, 3: 3})`, `[b, a, 3]`},
+		{`values({"b": 1, "a": 2, 3: 3})`, `[1, 2, 3]`},
+		{`entries({"b": 1, "a": 2})`, `[[b, 1], [a, 2]]`},
+		{`keys({})`, `[]`},
+		{`has({"a": 1}, "a")`, `true`},
+		{`has({"a": 1}, "b")`, `false`},
+		{`has({"a": first([])}, "a")`, `true`},
+		{`delete({"a": 1, "b": 2, "c": 3}, "b")`, `{a: 1, c: 3}`},
+		{`delete({"a": 1}, "b")`, `{a: 1}`},
+		{`let h = {"a": 1}; delete(h, "a"); h`, `{a: 1}`},
+		{`merge({"a": 1, "b": 2}, {"b": 3, "c": 4})`, `{a: 1, b: 3, c: 4}`},
+		{`merge()`, `{}`},
+		{`len({"a": 1, "b": 2})`, `2`},
+		{`{"z": 1, "y": 2, "x": 3, "z": 4}`, `{z: 4, y: 2, x: 3}`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s, expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestHashBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedMsg string
+	}{
+		{`keys([1])`, "argument to 'keys' must be HASH, got ARRAY"},
+		{`has({}, fn(x) { x })`, "unusable as hash key: FUNCTION"},
+		{`delete([], "a")`, "argument 1 to 'delete' must be HASH, got ARRAY"},
+		{`merge({}, 1)`, "argument 2 to 'merge' must be HASH, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		checkErrorObject(t, testEval(t, tt.input), tt.expectedMsg)
+	}
+}
+
+func TestTypeBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`type(1)`, `INTEGER`},
+		{`type("a")`, `STRING`},
+		{`type(true)`, `BOOLEAN`},
+		{`type([])`, `ARRAY`},
+		{`type({})`, `HASH`},
+		{`type(first([]))`, `NULL`},
+		{`type(fn() {})`, `FUNCTION`},
+		{`type(len)`, `BUILTIN`},
+		{`isInt(1)`, `true`},
+		{`isInt("1")`, `false`},
+		{`isString("a")`, `true`},
+		{`isBool(false)`, `true`},
+		{`isArray([])`, `true`},
+		{`isHash({})`, `true`},
+		{`isNull(first([]))`, `true`},
+		{`isFunction(len)`, `true`},
+		{`isFunction(fn() {})`, `true`},
+		{`isFunction(1)`, `false`},
+		{`int("42")`, `42`},
+		{`int("-7")`, `-7`},
+		{`int(true)`, `1`},
+		{`int(5)`, `5`},
+		{`str(42)`, `42`},
+		{`str([1, "a"])`, `[1, a]`},
+		{`bool(first([]))`, `false`},
+		{`bool(0)`, `true`},
+		{`bool("true")`, `true`},
+		{`bool("false")`, `false`},
+		{`array("abc")`, `[a, b, c]`},
+		{`array({"a": 1})`, `[[a, 1]]`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s, expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestTypeBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedMsg string
+	}{
+		{`type()`, "wrong number of arguments, got 0, want 1"},
+		{`int("abc")`, `could not convert "abc" to INTEGER`},
+		{`int("")`, `could not convert "" to INTEGER`},
+		{`int([])`, "cannot convert ARRAY to INTEGER"},
+		{`bool("yes")`, `could not convert "yes" to BOOLEAN`},
+		{`bool("")`, `could not convert "" to BOOLEAN`},
+		{`array(1)`, "cannot convert INTEGER to ARRAY"},
+	}
+
+	for _, tt := range tests {
+		checkErrorObject(t, testEval(t, tt.input), tt.expectedMsg)
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	result, ok := evaluated.(*object.Array)
 	if !ok {
 		t.Fatalf("object is not Array, got %T (%+v)", evaluated, evaluated)
@@ -312,11 +677,13 @@ func TestArrayIndexExpressions(t *testing.T) {
 		{input: "let myArray = [1, 2, 3]; myArray[0] + myArray[1] + myArray[2];", expected: 6},
 		{input: "let myArray = [1, 2, 3]; let i = myArray[0]; myArray[i]", expected: 2},
 		{input: "[1, 2, 3][3]", expected: nil},
-		{input: "[1, 2, 3][-1]", expected: nil},
+		{input: "[1, 2, 3][-1]", expected: 3},
+		{input: "[1, 2, 3][-3]", expected: 1},
+		{input: "[1, 2, 3][-4]", expected: nil},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			checkIntegerObject(t, evaluated, int64(integer))
@@ -327,6 +694,64 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: `"abc"[0]`, expected: "a"},
+		{input: `"abc"[2]`, expected: "c"},
+		{input: `"héllo"[1]`, expected: "é"},
+		{input: `let s = "abc"; s[1 + 1]`, expected: "c"},
+		{input: `"abc"[3]`, expected: nil},
+		{input: `"héllo"[-4]`, expected: "é"},
+		{input: `"abc"[-4]`, expected: nil},
+		{input: `""[0]`, expected: nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		str, ok := tt.expected.(string)
+		if ok {
+			checkStringObject(t, evaluated, str)
+			continue
+		}
+
+		checkNullObject(t, evaluated)
+	}
+}
+
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: "[1, 2, 3, 4][1:3]", expected: []int64{2, 3}},
+		{input: "[1, 2, 3, 4][:2]", expected: []int64{1, 2}},
+		{input: "[1, 2, 3, 4][2:]", expected: []int64{3, 4}},
+		{input: "[1, 2, 3, 4][:]", expected: []int64{1, 2, 3, 4}},
+		{input: "[1, 2, 3, 4][-2:]", expected: []int64{3, 4}},
+		{input: "[1, 2, 3, 4][:-1]", expected: []int64{1, 2, 3}},
+		{input: "[1, 2, 3, 4][3:1]", expected: []int64{}},
+		{input: "[1, 2, 3, 4][-10:10]", expected: []int64{1, 2, 3, 4}},
+		{input: "let a = [1, 2, 3]; let i = 1; a[i:i + 1]", expected: []int64{2}},
+		{input: `"héllo"[1:3]`, expected: "él"},
+		{input: `"hello"[-3:]`, expected: "llo"},
+		{input: `"hello"[:0]`, expected: ""},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case []int64:
+			checkIntegerArray(t, evaluated, expected)
+		case string:
+			checkStringObject(t, evaluated, expected)
+		}
+	}
+}
+
 func TestHashLiterals(t *testing.T) {
 	input := `let two = "two";
 	{
@@ -338,7 +763,7 @@ func TestHashLiterals(t *testing.T) {
 		false: 6
 	}`
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	result, ok := evaluated.(*object.Hash)
 	if !ok {
 		t.Fatalf("Eval didn't return Hash, got %T (%+v)", evaluated, evaluated)
@@ -378,10 +803,13 @@ func TestHashIndexExpressions(t *testing.T) {
 		{input: `{5: 5}[5]`, expected: 5},
 		{input: `{true: 5}[true]`, expected: 5},
 		{input: `{false: 5}[false]`, expected: 5},
+		{input: `{[1, "a"]: 5}[[1, "a"]]`, expected: 5},
+		{input: `{[1, [2]]: 5}[[1, [2]]]`, expected: 5},
+		{input: `{[1, 2]: 5}[[2, 1]]`, expected: nil},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			checkIntegerObject(t, evaluated, int64(integer))
@@ -392,14 +820,337 @@ func TestHashIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestMemberExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{input: `{"foo": 5}.foo`, expected: 5},
+		{input: `{"foo": 5}.bar`, expected: nil},
+		{input: `let h = {"a": {"b": 2}}; h.a.b`, expected: 2},
+		{input: `let h = {"f": fn(x) { x * 2 }}; h.f(3)`, expected: 6},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			checkIntegerObject(t, evaluated, int64(integer))
+			continue
+		}
+
+		checkNullObject(t, evaluated)
+	}
+}
+
+func TestStructs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, y }; Point", "struct Point { x, y }"},
+		{"struct Point { x, y }; Point(1, 2)", "Point{x: 1, y: 2}"},
+		{"struct Point { x, y }; let p = Point(1, 2); p.x + p.y", "3"},
+		{"struct Empty {}; Empty()", "Empty{}"},
+		{"struct Box { f }; Box(fn(x) { x * 2 }).f(4)", "8"},
+		{"struct Line { from, to }; struct P { x }; Line(P(1), P(2)).to.x", "2"},
+		{"struct P { x }; P(1) == P(1)", "true"},
+		{"struct P { x }; P(1) == P(2)", "false"},
+		{"struct P { x }; struct Q { x }; P(1) == Q(1)", "false"},
+		{"struct P { x }; type(P(1))", "STRUCT"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s, expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMethodCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"abc".upper()`, "ABC"},
+		{`"a,b".split(",")`, "[a, b]"},
+		{`" x ".trim().len()`, "1"},
+		{`[1, 2, 3].map(fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`[3, 1, 2].sort().reverse()`, "[3, 2, 1]"},
+		{`push(push([], 1), 2) == [].push(1).push(2)`, "true"},
+		{`{"a": 1, "b": 2}.keys()`, "[a, b]"},
+		{`{"a": 1}.merge({"b": 2})`, "{a: 1, b: 2}"},
+		{`{"keys": fn() { "own" }}.keys()`, "own"},
+		{`let keys = {"a": 1}.keys; keys()`, "[a]"},
+		{`{"keys": 1}.keys`, "1"},
+		{`{"a": 1}.b`, "null"},
+		{`struct P { x }; let t = P(1).type; t()`, "STRUCT"},
+		{`42.str()`, "42"},
+		{`42.type()`, "INTEGER"},
+		{`let up = "abc".upper; up()`, "ABC"},
+		{`struct P { f }; P(fn(x) { x + 1 }).f(1)`, "2"},
+		{`json.parse("[1]").len()`, "1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s, expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let g = fn() { yield 1; yield 2 }; collect(g())`, "[1, 2]"},
+		{`let g = fn() { yield 1 }(); [next(g), next(g), next(g)]`, "[{value: 1, done: false}, {value: null, done: true}, {value: null, done: true}]"},
+		{`let g = fn() { let none = if (false) { 1 }; yield none }(); [next(g), next(g).done]`, "[{value: null, done: false}, true]"},
+		{`let outer = fn() { let g = fn() { yield 1; yield 2 }; collect(g()) }; outer()`, "[1, 2]"},
+		{`let outer = fn() { let g = fn() { yield 1 }; g }; type(outer())`, "FUNCTION"},
+		{`let f = fn() { let xs = collect(fn() { yield 1 }()); len(xs) + 5 }; f()`, "6"},
+		{`let call = fn(g) { g() }; let f = fn() { let it = call(fn() { yield 1 }); 5 }; f()`, "5"},
+		{`let g = fn() { yield 1; return 5; yield 2 }; collect(g())`, "[1]"},
+		{`let g = fn() { let x = yield 1; yield x }; collect(g())`, "[1, null]"},
+		{`let from = fn(n) { yield n; yield n + 1 }; collect(map(from(5), fn(x) { x * 10 }))`, "[50, 60]"},
+		{`let g = fn() { yield 1 }; type(g())`, "ITERATOR"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s, expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestIteratorBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`collect(iter([1, 2]))`, "[1, 2]"},
+		{`collect(iter("ab"))`, "[a, b]"},
+		{`collect(iter({"a": 1}))`, "[[a, 1]]"},
+		{`let it = iter([1]); [next(it).value, next(it).value]`, "[1, null]"},
+		{`collect(take(count(), 3))`, "[0, 1, 2]"},
+		{`collect(take(count(10, -2), 3))`, "[10, 8, 6]"},
+		{`take([1, 2, 3], 2)`, "[1, 2]"},
+		{`take([1, 2, 3], 5)`, "[1, 2, 3]"},
+		{`collect(take(map(count(), fn(x) { x * x }), 4))`, "[0, 1, 4, 9]"},
+		{`collect(take(filter(count(1), fn(x) { x > 3 }), 3))`, "[4, 5, 6]"},
+		{`reduce(take(count(1), 4), fn(a, b) { a + b })`, "10"},
+		{`reduce(take(count(1), 4), fn(a, b) { a + b }, 10)`, "20"},
+		{`reduce(iter([]), fn(a, b) { a + b })`, "null"},
+		{`each(take(count(), 3), fn(x) { x })`, "null"},
+		{`count().map(fn(x) { x + 1 }).take(2).collect()`, "[1, 2]"},
+		{`[1, 2, 3].iter().filter(fn(x) { x > 1 }).collect()`, "[2, 3]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s, expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestIteratorErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`iter(1)`, "cannot iterate over INTEGER"},
+		{`next([1])`, "argument to 'next' must be ITERATOR, got ARRAY"},
+		{`let g = fn() { yield foo }(); next(g)`, "identifier not found: foo"},
+		{`take(count(), -1)`, "count passed to 'take' must not be negative, got -1"},
+		{`take(1, 1)`, "argument 1 to 'take' must be ITERATOR, got INTEGER"},
+		{`map(count(), 1)`, "argument 2 to 'map' must be FUNCTION, got INTEGER"},
+		{`collect(map(iter([1, true]), fn(x) { -x }))`, "unknown operator: -BOOLEAN"},
+		{`collect(fn() { yield 1; 1 + true }())`, "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if err, ok := evaluated.(*object.Error); ok {
+			if err.Message != tt.expected {
+				t.Errorf("wrong error for %s, expected %q, got %q", tt.input, tt.expected, err.Message)
+			}
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s, expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestGeneratorEndsAfterError(t *testing.T) {
+	it, ok := testEval(t, `fn() { yield foo }()`).(*object.Iterator)
+	if !ok {
+		t.Fatalf("expected an iterator")
+	}
+
+	if val, ok := it.Next(); !ok || !isError(val) {
+		t.Errorf("expected the error of the generator, got %v, %t", val, ok)
+	}
+	if val, ok := it.Next(); ok {
+		t.Errorf("expected the generator to have ended, got %v", val)
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`await(spawn fn(x) { x * 2 }(21))`, "42"},
+		{`let add = fn(a, b) { a + b }; let tasks = map(range(5), fn(i) { spawn add(i, 10) }); map(tasks, await)`, "[10, 11, 12, 13, 14]"},
+		{`let ch = channel(1); send(ch, 1); recv(ch)`, "{value: 1, done: false}"},
+		{`let ch = channel(); let t = spawn send(ch, "hi"); [recv(ch).value, await(t)]`, "[hi, null]"},
+		{`let ch = channel(2); ch.send(1); ch.close(); [ch.recv(), ch.recv()]`, "[{value: 1, done: false}, {value: null, done: true}]"},
+		{`let ch = channel(1); send(ch, first([])); let a = recv(ch); close(ch); [a, recv(ch)]`, "[{value: null, done: false}, {value: null, done: true}]"},
+		{`let ch = channel(); spawn fn() { each(range(3), fn(i) { send(ch, i) }); close(ch) }(); collect(take(count(), 3).map(fn(i) { recv(ch).value }))`, "[0, 1, 2]"},
+		{`let h = {"f": fn(x) { x + 1 }}; (spawn h.f(1)).await()`, "2"},
+		{`let a = [1, {}]; let f = spawn freeze(a); let r = spawn isFrozen(a[1]); await(f); await(r); isFrozen(a[1])`, "true"},
+		{`let a = channel(); let b = channel(1); send(b, "b"); select([a, b])`, "[1, b]"},
+		{`let a = channel(1); select([[a, 5]]); recv(a).value`, "5"},
+		{`select([channel()], "none")`, "[-1, none]"},
+		{`let t = spawn fn() { 7 }(); select([channel(), t])`, "[1, 7]"},
+		{`let ch = channel(1); close(ch); select([ch])`, "[0, null]"},
+		{`type(channel(3))`, "CHANNEL"},
+		{`channel(3)`, "channel(3)"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s, expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestConcurrencyErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`await(spawn fn() { 1 + true }())`, "type mismatch: INTEGER + BOOLEAN"},
+		{`spawn missing()`, "identifier not found: missing"},
+		{`let ch = channel(); close(ch); send(ch, 1)`, "send on closed channel"},
+		{`let ch = channel(); close(ch); close(ch)`, "close of closed channel"},
+		{`let ch = channel(); close(ch); select([[ch, 1]])`, "send on closed channel"},
+		{`channel(-1)`, "size passed to 'channel' must not be negative, got -1"},
+		{`channel(100000000000)`, "size passed to 'channel' must be at most 1048576, got 100000000000"},
+		{`recv(1)`, "argument to 'recv' must be CHANNEL, got INTEGER"},
+		{`await(1)`, "argument to 'await' must be TASK, got INTEGER"},
+		{`select([1])`, "select case 0 must be CHANNEL, TASK or [CHANNEL, value]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		checkErrorObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestConcurrentEnvironment(t *testing.T) {
+	input := `
+	let total = channel(100);
+	let worker = fn(i) { let square = i * i; send(total, square); square };
+	let tasks = map(range(100), fn(i) { spawn worker(i) });
+	let results = map(tasks, await);
+	let sum = reduce(map(range(100), fn(i) { recv(total).value }), fn(a, b) { a + b });
+	[len(results), sum]
+	`
+
+	evaluated := testEval(t, input)
+	if evaluated.Inspect() != "[100, 328350]" {
+		t.Errorf("wrong result, expected [100, 328350], got %s", evaluated.Inspect())
+	}
+}
+
+func TestAwaitError(t *testing.T) {
+	env := object.NewEnvironment()
+	eval := func(input string) object.Object {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		checkParserErrors(t, input, p)
+		return Eval(program, env)
+	}
+
+	eval(`let f = fn() { 1 + true }; let t = spawn f(); let wait = fn() { await(t) };`)
+	first, _ := eval("wait()").(*object.Error)
+	second, _ := eval("wait()").(*object.Error)
+	if first == nil || second == nil {
+		t.Fatalf("expected errors, got %v and %v", first, second)
+	}
+
+	for _, err := range []*object.Error{first, second} {
+		if len(err.Trace) != 2 || err.Trace[0].Function != "f" || err.Trace[1].Function != "wait" {
+			t.Errorf("wrong trace %+v", err.Trace)
+		}
+	}
+}
+
+func TestPipeExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, 2, 3] |> len`, "3"},
+		{`[1, 2, 3] |> map(fn(x) { x * 2 }) |> filter(fn(x) { x > 2 })`, "[4, 6]"},
+		{`"a,b" |> split(",") |> join("-")`, "a-b"},
+		{`1 + 2 |> fn(x, y) { x * y }(10)`, "30"},
+		{`let h = {"inc": fn(x) { x + 1 }}; 1 |> h.inc |> h.inc()`, "3"},
+		{`count() |> map(fn(x) { x * x }) |> take(3) |> collect`, "[0, 1, 4]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s, expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let double = x => x * 2; double(21)`, "42"},
+		{`let add = (a, b) => a + b; add(1, 2)`, "3"},
+		{`let one = () => 1; one()`, "1"},
+		{`map([1, 2, 3], x => x * x)`, "[1, 4, 9]"},
+		{`[1, 2, 3].filter(x => x > 1)`, "[2, 3]"},
+		{`let adder = x => y => x + y; adder(1)(2)`, "3"},
+		{`let f = x => { let y = x + 1; y * 2 }; f(1)`, "4"},
+		{`let n = 10; let f = x => x + n; f(1)`, "11"},
+		{`[1, 2] |> map(x => x + 1)`, "[2, 3]"},
+		{`let g = () => { yield 1; yield 2 }; collect(g())`, "[1, 2]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s, expected %s, got %s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
 func TestErrorHandling(t *testing.T) {
 	tests := []struct {
 		input       string
 		expectedMsg string
 	}{
 		{"5 + true;", "type mismatch: INTEGER + BOOLEAN"},
+		{"1 |> 2", "not a function: INTEGER"},
+		{"(1 + true) |> len", "type mismatch: INTEGER + BOOLEAN"},
 		{"5 + true; 5;", "type mismatch: INTEGER + BOOLEAN"},
 		{"-true", "unknown operator: -BOOLEAN"},
+		{"10 / (5 - 5)", "division by zero"},
 		{"true + false;", "unknown operator: BOOLEAN + BOOLEAN"},
 		{"5; true + false; 5", "unknown operator: BOOLEAN + BOOLEAN"},
 		{"if (10 > 1) { true + false; }", "unknown operator: BOOLEAN + BOOLEAN"},
@@ -407,10 +1158,25 @@ func TestErrorHandling(t *testing.T) {
 		{"foobar", "identifier not found: foobar"},
 		{`"Hello" - "World!"`, "unknown operator: STRING - STRING"},
 		{`{"name": "Monkey"}[fn(x) { x }];`, "unusable as hash key: FUNCTION"},
+		{`{[1, fn(x) { x }]: 1}`, "unusable as hash key: ARRAY"},
+		{"const a = 1; let a = 2;", "cannot rebind constant: a"},
+		{"struct Point { x, y }; Point(1)", "wrong number of fields for struct Point, got 1, want 2"},
+		{"fn(x, y) { x }(1)", "wrong number of arguments, got 1, want 2"},
+		{"struct Point { x, y }; Point(1, 2).z", "unknown field z on struct Point"},
+		{"const Point = 1; struct Point { x }", "cannot rebind constant: Point"},
+		{"const a = 1; const a = 2;", "cannot rebind constant: a"},
+		{"let f = fn() { const b = 1; let b = 2; }; f()", "cannot rebind constant: b"},
+		{"5.foo", "member access not supported: INTEGER"},
+		{"5.foo()", "unknown method foo on INTEGER"},
+		{`{"a": 1}.foo()`, "unknown method foo on HASH"},
+		{`"abc".map(fn(x) { x })`, "unknown method map on STRING"},
+		{`{"a": 1}.a()`, "not a function: INTEGER"},
+		{`{"a": 1}[0:1]`, "slice operator not supported: HASH"},
+		{`[1, 2]["a":]`, "slice bound must be INTEGER, got STRING"},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 
 		errObj, ok := evaluated.(*object.Error)
 		if !ok {
@@ -424,14 +1190,155 @@ func TestErrorHandling(t *testing.T) {
 	}
 }
 
-func testEval(input string) object.Object {
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedLine   int
+		expectedColumn int
+		expectedTrace  []object.Frame
+	}{
+		{"5 + true;", 1, 3, nil},
+		{"let x = 1;\n  foobar", 2, 3, nil},
+		{"len(1, 2)", 1, 1, nil},
+		{"fn(x, y) { x }(1)", 1, 1, nil},
+		{
+			"let f = fn(x) {\n  -x\n};\nlet g = x => f(x);\ng(true)",
+			2, 3,
+			[]object.Frame{{Function: "f", Line: 4, Column: 14}, {Function: "g", Line: 5, Column: 1}},
+		},
+		{
+			"let h = {\"f\": fn() { 1 + \"a\" }};\nh.f()",
+			1, 24,
+			[]object.Frame{{Function: "", Line: 2, Column: 2}},
+		},
+		{"let f = fn(x) { x + true }; 1 |> f", 1, 19, []object.Frame{{Function: "f", Line: 1, Column: 34}}},
+		{"await(spawn len(1))", 1, 13, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned, got %T (%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Line != tt.expectedLine || errObj.Column != tt.expectedColumn {
+			t.Errorf("%q - position wrong, expected %d:%d, got %d:%d", tt.input, tt.expectedLine, tt.expectedColumn, errObj.Line, errObj.Column)
+		}
+		if len(errObj.Trace) != len(tt.expectedTrace) {
+			t.Errorf("%q - trace wrong, expected %v, got %v", tt.input, tt.expectedTrace, errObj.Trace)
+			continue
+		}
+		for i, frame := range tt.expectedTrace {
+			if errObj.Trace[i] != frame {
+				t.Errorf("%q - trace[%d] wrong, expected %v, got %v", tt.input, i, frame, errObj.Trace[i])
+			}
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"exit()", 0},
+		{"exit(3); 1", 3},
+		{"let f = fn() { if (true) { exit(4) }; 1 }; f() + 1", 4},
+		{"[1, 2] |> map(fn(x) { exit(x) })", 1},
+		{"let gen = fn() { yield 1; exit(5) }; collect(gen())", 5},
+		{"await(spawn exit(6))", 6},
+		{"exit(-1)", "code passed to 'exit' must be between 0 and 255, got -1"},
+		{`exit("1")`, "argument to 'exit' must be INTEGER, got STRING"},
+		{"exit(1, 2)", "wrong number of arguments, got 2, want 0 or 1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			exit, ok := evaluated.(*object.Exit)
+			if !ok {
+				t.Errorf("%q - no exit object returned, got %T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if exit.Code != expected {
+				t.Errorf("%q - wrong exit code, expected %d, got %d", tt.input, expected, exit.Code)
+			}
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("%q - no error object returned, got %T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message, expected %q, got %q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestConstant(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // "" if not constant
+	}{
+		{"5", "5"},
+		{"-(2 * 3) + 1", "-5"},
+		{`"a" + "b"`, "ab"},
+		{"!true", "false"},
+		{"[1, 2 * 2]", "[1, 4]"},
+		{`{"a": 1}`, "{a: 1}"},
+		{"1 / 0", ""},
+		{"1 / (2 - 2)", ""},
+		{"x + 1", ""},
+		{"len([])", ""},
+		{"fn() { 1 }", ""},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		expr := program.Statements[0].(*ast.ExpressionStatement).Expression
+
+		val, ok := Constant(expr)
+		switch {
+		case tt.expected == "" && ok:
+			t.Errorf("%s: expected no constant, got %s", tt.input, val.Inspect())
+		case tt.expected != "" && !ok:
+			t.Errorf("%s: expected constant %s, got none", tt.input, tt.expected)
+		case ok && val.Inspect() != tt.expected:
+			t.Errorf("%s: wrong constant, got %s, want %s", tt.input, val.Inspect(), tt.expected)
+		}
+	}
+}
+
+func testEval(t *testing.T, input string) object.Object {
+	t.Helper()
 	l := lexer.New(input)
 	p := parser.New(l)
 	env := object.NewEnvironment()
 	program := p.ParseProgram()
+	checkParserErrors(t, input, p)
 	return Eval(program, env)
 }
 
+func checkParserErrors(t *testing.T, input string, p *parser.Parser) {
+	t.Helper()
+	errors := p.Errors()
+	if len(errors) == 0 {
+		return
+	}
+
+	t.Errorf("parser has %d errors for %q", len(errors), input)
+	for _, msg := range errors {
+		t.Errorf("parser error: %q", msg)
+	}
+	t.FailNow()
+}
+
 func checkNullObject(t *testing.T, obj object.Object) bool {
 	t.Helper()
 	if obj != NULL {
@@ -455,6 +1362,53 @@ func checkIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	return true
 }
 
+func checkStringObject(t *testing.T, obj object.Object, expected string) bool {
+	t.Helper()
+	result, ok := obj.(*object.String)
+	if !ok {
+		t.Errorf("object is not String, got %T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value, got %q want %q", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func checkIntegerArray(t *testing.T, obj object.Object, expected []int64) bool {
+	t.Helper()
+	result, ok := obj.(*object.Array)
+	if !ok {
+		t.Errorf("object is not Array, got %T (%+v)", obj, obj)
+		return false
+	}
+	if len(result.Elements) != len(expected) {
+		t.Errorf("wrong num of elements, want %d, got %d", len(expected), len(result.Elements))
+		return false
+	}
+	for i, el := range expected {
+		if !checkIntegerObject(t, result.Elements[i], el) {
+			return false
+		}
+	}
+	return true
+}
+
+func checkErrorObject(t *testing.T, obj object.Object, expected string) bool {
+	t.Helper()
+	errObj, ok := obj.(*object.Error)
+	if !ok {
+		t.Errorf("object is not Error, got %T (%+v)", obj, obj)
+		return false
+	}
+	if errObj.Message != expected {
+		t.Errorf("wrong error message, expected %q, got %q", expected, errObj.Message)
+		return false
+	}
+	return true
+}
+
 func checkBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
 	t.Helper()
 	result, ok := obj.(*object.Boolean)
@@ -0,0 +1,121 @@
+// evaluator/generator.go
+
+package evaluator
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/solbero/monkey/object"
+)
+
+// errGeneratorAborted unwinds the body of a generator whose iterator has been
+// garbage collected before it was exhausted.
+var errGeneratorAborted = &object.Error{Message: "generator aborted"}
+
+// generator runs the body of a generator function on its own goroutine. The
+// consumer and the body take turns: Next resumes the body and waits for the
+// next yielded value, yield hands the value over and waits to be resumed.
+//
+// The body is only on the tracer's call stack while it runs, so a suspended
+// generator does not stay on top of the consumer's calls.
+type generator struct {
+	mu      sync.Mutex
+	fn      *object.Function
+	env     *object.Environment
+	resume  chan struct{}
+	values  chan object.Object
+	started bool
+	done    bool
+	running atomic.Bool
+	traced  bool // whether the body is on the tracer's call stack
+}
+
+func newGenerator(fn *object.Function, env *object.Environment) *object.Iterator {
+	g := &generator{
+		fn:     fn,
+		env:    env,
+		resume: make(chan struct{}),
+		values: make(chan object.Object),
+	}
+	env.SetYield(g.yield)
+
+	it := &object.Iterator{Next: g.next}
+	runtime.SetFinalizer(it, func(*object.Iterator) { close(g.resume) })
+
+	return it
+}
+
+func (g *generator) next() (object.Object, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.done {
+		return nil, false
+	}
+	if !g.started {
+		g.started = true
+		go g.run()
+	}
+
+	g.running.Store(true)
+	g.resume <- struct{}{}
+	val, ok := <-g.values
+	g.running.Store(false)
+
+	// The body has ended if it failed, see run.
+	if !ok || isError(val) {
+		g.done = true
+	}
+	if !ok {
+		return nil, false
+	}
+	return val, true
+}
+
+func (g *generator) run() {
+	defer close(g.values)
+
+	if _, ok := <-g.resume; !ok {
+		return
+	}
+
+	g.enter()
+	result := unwrapReturnValue(Eval(g.fn.Body, g.env))
+	g.leave()
+	if isError(result) && result != errGeneratorAborted {
+		g.values <- result
+	}
+}
+
+func (g *generator) yield(val object.Object) object.Object {
+	if !g.running.Load() {
+		return newError("yield outside of running generator")
+	}
+
+	g.leave()
+	g.values <- val
+	if _, ok := <-g.resume; !ok {
+		return errGeneratorAborted
+	}
+	g.enter()
+
+	return NULL
+}
+
+// enter tells the tracer, if any, that the body runs again.
+func (g *generator) enter() {
+	if tracer, ok := g.env.Tracer(); ok {
+		tracer.Call(g.fn, g.env)
+		g.traced = true
+	}
+}
+
+// leave tells the tracer, if any, that the body has suspended or ended.
+func (g *generator) leave() {
+	if tracer, ok := g.env.Tracer(); ok && g.traced {
+		tracer.Return(g.fn)
+		g.traced = false
+	}
+}
@@ -0,0 +1,233 @@
+// evaluator/json.go
+
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/solbero/monkey/object"
+)
+
+// DecodeJSON converts JSON text into Monkey values. Objects become hashes
+// with their keys in document order, numbers must be integers.
+func DecodeJSON(data []byte) (object.Object, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	obj, err := decodeJSONValue(dec)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after top-level value")
+	}
+
+	return obj, nil
+}
+
+func decodeJSONValue(dec *json.Decoder) (object.Object, error) {
+	tok, err := dec.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			return decodeJSONArray(dec)
+		}
+		return decodeJSONObject(dec)
+	case string:
+		return &object.String{Value: tok}, nil
+	case json.Number:
+		value, err := strconv.ParseInt(tok.String(), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("number %s is not representable as INTEGER", tok)
+		}
+		return &object.Integer{Value: value}, nil
+	case bool:
+		return nativeBoolToBooleanObject(tok), nil
+	default:
+		return NULL, nil
+	}
+}
+
+func decodeJSONArray(dec *json.Decoder) (object.Object, error) {
+	elements := []object.Object{}
+	for dec.More() {
+		el, err := decodeJSONValue(dec)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, el)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return &object.Array{Elements: elements}, nil
+}
+
+func decodeJSONObject(dec *json.Decoder) (object.Object, error) {
+	hash := object.NewHash()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		key := &object.String{Value: tok.(string)}
+		value, err := decodeJSONValue(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		hash.Set(key.HashKey(), object.HashPair{Key: key, Value: value})
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return hash, nil
+}
+
+// EncodeJSON converts a Monkey value into JSON text. Hash keys must be
+// strings and are written in sorted order. A non-empty indent produces
+// multi-line output with one indent per nesting level.
+func EncodeJSON(obj object.Object, indent string) ([]byte, error) {
+	var out bytes.Buffer
+	if err := encodeJSONValue(&out, obj); err != nil {
+		return nil, err
+	}
+
+	if indent == "" {
+		return out.Bytes(), nil
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out.Bytes(), "", indent); err != nil {
+		return nil, err
+	}
+
+	return indented.Bytes(), nil
+}
+
+func encodeJSONValue(out *bytes.Buffer, obj object.Object) error {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *object.Boolean:
+		out.WriteString(strconv.FormatBool(obj.Value))
+	case *object.Null:
+		out.WriteString("null")
+	case *object.String:
+		encodeJSONString(out, obj.Value)
+	case *object.Array:
+		out.WriteByte('[')
+		for i, el := range obj.Elements {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := encodeJSONValue(out, el); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case *object.Hash:
+		pairs := obj.OrderedPairs()
+		keys := make([]string, len(pairs))
+		values := make(map[string]object.Object, len(pairs))
+		for i, pair := range pairs {
+			key, ok := pair.Key.(*object.String)
+			if !ok {
+				return fmt.Errorf("cannot encode hash key of type %s as JSON", pair.Key.Type())
+			}
+			keys[i] = key.Value
+			values[key.Value] = pair.Value
+		}
+		sort.Strings(keys)
+
+		out.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			encodeJSONString(out, key)
+			out.WriteByte(':')
+			if err := encodeJSONValue(out, values[key]); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	default:
+		return fmt.Errorf("cannot encode %s as JSON", obj.Type())
+	}
+
+	return nil
+}
+
+func encodeJSONString(out *bytes.Buffer, s string) {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	out.Truncate(out.Len() - 1) // drop the newline written by Encode
+}
+
+var jsonModule = map[string]*object.Builtin{
+	"parse": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if err := checkArgs("json.parse", args, object.STRING_OBJ); err != nil {
+				return err
+			}
+
+			obj, err := DecodeJSON([]byte(args[0].(*object.String).Value))
+			if err != nil {
+				return newError("invalid JSON: %s", err)
+			}
+
+			return obj
+		},
+	},
+	"stringify": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments, got %d, want 1 or 2", len(args))
+			}
+
+			indent := ""
+			if len(args) == 2 {
+				switch arg := args[1].(type) {
+				case *object.Integer:
+					if arg.Value < 0 {
+						return newError("indent passed to 'json.stringify' must not be negative, got %d", arg.Value)
+					}
+					indent = strings.Repeat(" ", int(arg.Value))
+				case *object.String:
+					indent = arg.Value
+				default:
+					return argumentError("json.stringify", 1, 2, object.INTERGER_OBJ, arg)
+				}
+			}
+
+			data, err := EncodeJSON(args[0], indent)
+			if err != nil {
+				return newError("%s", err)
+			}
+
+			return &object.String{Value: string(data)}
+		},
+	},
+}
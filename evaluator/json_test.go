@@ -0,0 +1,79 @@
+// evaluator/json_test.go
+
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/solbero/monkey/object"
+)
+
+func TestJSONBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json.parse("42")`, `42`},
+		{`json.parse("\"hi\"")`, `hi`},
+		{`json.parse("[1, true, null, \"a\"]")`, `[1, true, null, a]`},
+		{`json.parse("{\"b\": 1, \"a\": [2]}")`, `{b: 1, a: [2]}`},
+		{`json.parse("{\"a\": {\"b\": 2}}").a.b`, `2`},
+		{`json.stringify({"b": 1, "a": [true, first([])], "c": "x\"y"})`, `{"a":[true,null],"b":1,"c":"x\"y"}`},
+		{`json.stringify([1, {"a": 2}], 2)`, "[\n  1,\n  {\n    \"a\": 2\n  }\n]"},
+		{`json.stringify("<&>")`, `"<&>"`},
+		{`json.stringify(json.parse("{\"a\":[1,2]}"))`, `{"a":[1,2]}`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s, expected %q, got %q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestJSONBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedMsg string
+	}{
+		{`json.parse("[1,")`, "invalid JSON: unexpected end of JSON input"},
+		{`json.parse("")`, "invalid JSON: unexpected EOF"},
+		{`json.parse("1.5")`, "invalid JSON: number 1.5 is not representable as INTEGER"},
+		{`json.parse("1 2")`, "invalid JSON: unexpected data after top-level value"},
+		{`json.parse(1)`, "argument to 'json.parse' must be STRING, got INTEGER"},
+		{`json.stringify(fn(x) { x })`, "cannot encode FUNCTION as JSON"},
+		{`json.stringify([len])`, "cannot encode BUILTIN as JSON"},
+		{`json.stringify({1: 2})`, "cannot encode hash key of type INTEGER as JSON"},
+	}
+
+	for _, tt := range tests {
+		checkErrorObject(t, testEval(t, tt.input), tt.expectedMsg)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	obj, err := DecodeJSON([]byte(`{"name": "monkey", "tags": ["a", "b"], "ok": true}`))
+	if err != nil {
+		t.Fatalf("DecodeJSON returned error: %s", err)
+	}
+
+	hash, ok := obj.(*object.Hash)
+	if !ok {
+		t.Fatalf("object is not Hash, got %T (%+v)", obj, obj)
+	}
+
+	if evalHashIndexExpression(hash, &object.String{Value: "ok"}) != TRUE {
+		t.Errorf("decoded boolean is not the TRUE singleton")
+	}
+
+	data, err := EncodeJSON(obj, "")
+	if err != nil {
+		t.Fatalf("EncodeJSON returned error: %s", err)
+	}
+
+	expected := `{"name":"monkey","ok":true,"tags":["a","b"]}`
+	if string(data) != expected {
+		t.Errorf("EncodeJSON wrong, expected %s, got %s", expected, data)
+	}
+}
@@ -0,0 +1,87 @@
+// evaluator/methods.go
+
+package evaluator
+
+import (
+	"github.com/solbero/monkey/ast"
+	"github.com/solbero/monkey/object"
+)
+
+// methods lists the builtins that can be called with method syntax on a
+// value of the given type. The receiver is passed as the first argument, so
+// "abc".upper() is upper("abc").
+var methods = map[object.ObjectType][]string{
+	object.STRING_OBJ: {
+		"len", "split", "trim", "trimLeft", "trimRight", "replace", "contains",
+		"startsWith", "endsWith", "indexOf", "upper", "lower", "repeat", "chars",
+		"substr", "int", "array", "iter",
+	},
+	object.ARRAY_OBJ: {
+		"len", "first", "last", "rest", "push", "join", "map", "filter", "reduce",
+		"each", "find", "any", "all", "sort", "reverse", "slice", "concat", "zip",
+		"flatten", "unique", "iter", "take",
+	},
+	object.HASH_OBJ: {
+		"len", "keys", "values", "entries", "has", "delete", "merge", "array",
+		"iter",
+	},
+	object.ITERATOR_OBJ: {
+		"next", "take", "collect", "map", "filter", "reduce", "each", "iter",
+	},
+	object.CHANNEL_OBJ: {"send", "recv", "close"},
+	object.TASK_OBJ:    {"await"},
+}
+
+// universalMethods can be called on a value of any type.
+var universalMethods = []string{"type", "str", "freeze", "isFrozen"}
+
+func findMethod(receiver object.Object, name string) (*object.Builtin, bool) {
+	for _, names := range [][]string{methods[receiver.Type()], universalMethods} {
+		for _, method := range names {
+			if method == name {
+				return builtins[name], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// bindMethod returns a builtin that calls method with receiver as its first
+// argument.
+func bindMethod(method *object.Builtin, receiver object.Object) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			return method.Fn(append([]object.Object{receiver}, args...)...)
+		},
+	}
+}
+
+// evalMethod resolves receiver.name to a callable. Hash entries and struct
+// fields holding functions take precedence over methods of the receiver's
+// type.
+func evalMethod(node *ast.MemberExpression, env *object.Environment) object.Object {
+	receiver := Eval(node.Object, env)
+	if isError(receiver) {
+		return receiver
+	}
+
+	name := node.Property.Value
+
+	switch receiver := receiver.(type) {
+	case *object.Hash:
+		if pair, ok := receiver.Pairs[(&object.String{Value: name}).HashKey()]; ok {
+			return pair.Value
+		}
+	case *object.Struct:
+		if idx := receiver.Definition.FieldIndex(name); idx >= 0 {
+			return receiver.Values[idx]
+		}
+	}
+
+	method, ok := findMethod(receiver, name)
+	if !ok {
+		return newError("unknown method %s on %s", name, receiver.Type())
+	}
+
+	return bindMethod(method, receiver)
+}
@@ -1,7 +1,12 @@
+// exec/exec.go
+
+// Package exec runs Monkey programs.
 package exec
 
 import (
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/solbero/monkey/evaluator"
 	"github.com/solbero/monkey/lexer"
@@ -9,50 +14,99 @@ import (
 	"github.com/solbero/monkey/parser"
 )
 
-func Start(in io.Reader, out io.Writer) {
-	env := object.NewEnvironment()
-	bytes, err := readContents(in)
-	if err != nil {
-		panic(err)
+// maxFrames is the number of trace frames printed at each end of a long
+// trace, e.g. of a deep recursion.
+const maxFrames = 10
+
+// ParseError reports the syntax errors of a program.
+type ParseError struct {
+	Name   string
+	Errors []string
+}
+
+func (e *ParseError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, msg := range e.Errors {
+		lines[i] = e.Name + ": " + msg
 	}
+	return strings.Join(lines, "\n")
+}
+
+// RuntimeError reports an error the program failed with.
+type RuntimeError struct {
+	Name string
+	Err  *object.Error
+}
 
-	s := string(bytes)
-	l := lexer.New(s)
-	p := parser.New(l)
+// Error returns the message of the error with its position, followed by the
+// function calls it unwound, one per line.
+func (e *RuntimeError) Error() string {
+	var out strings.Builder
 
-	program := p.ParseProgram()
-	if len(p.Errors()) != 0 {
-		printParserErrors(out, p.Errors())
-		return
+	out.WriteString(e.position(e.Err.Line, e.Err.Column) + ": " + e.Err.Message)
+
+	trace := e.Err.Trace
+	for i, frame := range trace {
+		if len(trace) > 2*maxFrames && i == maxFrames {
+			fmt.Fprintf(&out, "\n\t... %d more", len(trace)-2*maxFrames)
+		}
+		if len(trace) > 2*maxFrames && i >= maxFrames && i < len(trace)-maxFrames {
+			continue
+		}
+
+		name := frame.Function
+		if name == "" {
+			name = "<anonymous>"
+		}
+		fmt.Fprintf(&out, "\n\tat %s (%s)", name, e.position(frame.Line, frame.Column))
 	}
 
-	evaluator.Eval(program, env)
+	return out.String()
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-	// io.WriteString(out, MONKEY_FACE)
-	// io.WriteString(out, "Woops! We ran into some monkey business here!\n")
-	io.WriteString(out, "parser errors:\n")
-	for _, msg := range errors {
-		io.WriteString(out, "\t"+msg+"\n")
+func (e *RuntimeError) position(line, column int) string {
+	if line == 0 {
+		return e.Name
 	}
+	return fmt.Sprintf("%s:%d:%d", e.Name, line, column)
 }
 
-func readContents(f io.Reader) ([]byte, error) {
-	data := make([]byte, 0, 1024)
-	for {
-		n, err := f.Read(data[len(data):cap(data)])
-		data = data[:len(data)+n]
-		if err != nil {
-			if err == io.EOF {
-				err = nil
-			}
-			return data, err
-		}
+// ExitError reports that the program called exit.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string { return fmt.Sprintf("exit status %d", e.Code) }
+
+// Start reads a program from in and runs it in env. The name of the program
+// is used in error messages. It returns a *ParseError, *RuntimeError or
+// *ExitError if the program fails or exits, or the error reading in.
+func Start(name string, in io.Reader, env *object.Environment) (object.Object, error) {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	return Run(name, string(src), env)
+}
+
+// Run runs the program src in env and returns its value.
+func Run(name, src string, env *object.Environment) (object.Object, error) {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{Name: name, Errors: p.Errors()}
+	}
 
-		if len(data) >= cap(data) {
-			d := append(data[:cap(data)], 0)
-			data = d[:len(data)]
+	switch result := evaluator.Eval(program, env).(type) {
+	case *object.Error:
+		return nil, &RuntimeError{Name: name, Err: result}
+	case *object.Exit:
+		if result.Code != 0 {
+			return nil, &ExitError{Code: result.Code}
 		}
+		return nil, nil
+	default:
+		return result, nil
 	}
 }
@@ -0,0 +1,111 @@
+// exec/exec_test.go
+
+package exec
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/solbero/monkey/object"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string // the value of the program, or the error it failed with
+		code     int    // the code of an *ExitError
+	}{
+		{"1 + 2", "3", 0},
+		{"let = 1", "test.mk: 1:5: Expected next token to be IDENT, got = instead\ntest.mk: 1:5: No prefix parse function for = found", 0},
+		{"1 @ 2", "test.mk: 1:3: Illegal character '@'", 0},
+		{"1 + true", "test.mk:1:3: type mismatch: INTEGER + BOOLEAN", 0},
+		{"let f = fn(x) { x + true };\nlet g = fn(x) { f(x) };\ng(1)", "test.mk:1:19: type mismatch: INTEGER + BOOLEAN\n\tat f (test.mk:2:17)\n\tat g (test.mk:3:1)", 0},
+		{"fn() { 1 + true }()", "test.mk:1:10: type mismatch: INTEGER + BOOLEAN\n\tat <anonymous> (test.mk:1:1)", 0},
+		{"exit(3); 1 + true", "exit status 3", 3},
+		{"exit(); 1 + true", "<nil>", 0},
+	}
+
+	for _, tt := range tests {
+		value, err := Run("test.mk", tt.src, object.NewEnvironment())
+
+		got := fmt.Sprint(value)
+		if value != nil {
+			got = value.Inspect()
+		}
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.expected {
+			t.Errorf("%q - wrong result, expected %q, got %q", tt.src, tt.expected, got)
+		}
+
+		switch err := err.(type) {
+		case *ParseError:
+			if !strings.HasPrefix(tt.expected, "test.mk: ") {
+				t.Errorf("%q - unexpected parse error", tt.src)
+			}
+		case *RuntimeError:
+			if !strings.HasPrefix(tt.expected, "test.mk:1:") {
+				t.Errorf("%q - unexpected runtime error", tt.src)
+			}
+		case *ExitError:
+			if err.Code != tt.code {
+				t.Errorf("%q - wrong exit code, expected %d, got %d", tt.src, tt.code, err.Code)
+			}
+		case nil:
+			if tt.code != 0 {
+				t.Errorf("%q - expected exit code %d, got none", tt.src, tt.code)
+			}
+		default:
+			t.Errorf("%q - unexpected error type %T", tt.src, err)
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	value, err := Start("test.mk", strings.NewReader("let x = 2; x * 21"), object.NewEnvironment())
+	if err != nil || value.Inspect() != "42" {
+		t.Errorf("wrong result, got %v, %v", value, err)
+	}
+}
+
+func TestRuntimeErrorTrace(t *testing.T) {
+	frames := func(n int) []object.Frame {
+		trace := make([]object.Frame, n)
+		for i := range trace {
+			trace[i] = object.Frame{Function: fmt.Sprintf("f%d", i), Line: i + 1, Column: 1}
+		}
+		return trace
+	}
+	lines := func(from, to int) string {
+		var out strings.Builder
+		for i := from; i < to; i++ {
+			fmt.Fprintf(&out, "\n\tat f%d (test.mk:%d:1)", i, i+1)
+		}
+		return out.String()
+	}
+
+	tests := []struct {
+		err      *object.Error
+		expected string
+	}{
+		{&object.Error{Message: "boom"}, "test.mk: boom"},
+		{&object.Error{Message: "boom", Line: 2, Column: 3}, "test.mk:2:3: boom"},
+		{&object.Error{Message: "boom", Line: 2, Column: 3, Trace: []object.Frame{{Line: 4, Column: 1}}}, "test.mk:2:3: boom\n\tat <anonymous> (test.mk:4:1)"},
+		{&object.Error{Message: "boom", Line: 1, Column: 1, Trace: frames(20)}, "test.mk:1:1: boom" + lines(0, 20)},
+		{&object.Error{Message: "boom", Line: 1, Column: 1, Trace: frames(25)}, "test.mk:1:1: boom" + lines(0, 10) + "\n\t... 5 more" + lines(15, 25)},
+	}
+
+	for i, tt := range tests {
+		err := &RuntimeError{Name: "test.mk", Err: tt.err}
+		if err.Error() != tt.expected {
+			t.Errorf("tests[%d] - wrong error\ngot:\n%s\nwant:\n%s", i, err.Error(), tt.expected)
+		}
+	}
+
+	_, err := Run("test.mk", "let f = fn(n) { if (n == 0) { 1 + true } else { f(n - 1) } };\nf(30)", object.NewEnvironment())
+	if err == nil || strings.Count(err.Error(), "\n\tat f") != 20 || !strings.Contains(err.Error(), "\n\t... 11 more\n") {
+		t.Errorf("wrong trace of a deep recursion, got:\n%v", err)
+	}
+}
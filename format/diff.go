@@ -0,0 +1,233 @@
+// format/diff.go
+
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+// Diff returns the changes from a to b as a unified diff with the given file
+// name, or "" if they are equal.
+func Diff(name, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	x, y := splitLines(a), splitLines(b)
+	ops := diffLines(x, y)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", name, name)
+
+	for start := 0; start < len(ops); {
+		// Find the next change and the extent of its hunk, merging changes
+		// that are less than two contexts apart.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*context {
+				break
+			}
+		}
+
+		from := max(first-context, start)
+		to := min(last+context+1, len(ops))
+
+		var hunk strings.Builder
+		oldStart, newStart := ops[from].x+1, ops[from].y+1
+		oldLines, newLines := 0, 0
+		for _, op := range ops[from:to] {
+			switch op.kind {
+			case ' ':
+				oldLines++
+				newLines++
+				hunk.WriteString(" " + x[op.x])
+			case '-':
+				oldLines++
+				hunk.WriteString("-" + x[op.x])
+			case '+':
+				newLines++
+				hunk.WriteString("+" + y[op.y])
+			}
+		}
+		if oldLines == 0 {
+			oldStart--
+		}
+		if newLines == 0 {
+			newStart--
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLines, newStart, newLines)
+		out.WriteString(hunk.String())
+
+		start = to
+	}
+
+	return out.String()
+}
+
+// splitLines splits s into lines that keep their newline. A missing final
+// newline is added so every line prints on its own.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n"
+	}
+	return lines
+}
+
+// edit is one line of a diff: kept (' '), removed ('-') or added ('+'). x and
+// y are the positions of the line in the old and new text; for additions x
+// is the position the line is inserted at, for removals y is.
+type edit struct {
+	kind byte
+	x, y int
+}
+
+// diffLines computes a shortest edit script from x to y. Common leading and
+// trailing lines are matched first, the rest is compared with Myers'
+// algorithm in linear space, so large files with few changes stay cheap.
+func diffLines(x, y []string) []edit {
+	d := &differ{x: x, y: y, ops: make([]edit, 0, max(len(x), len(y)))}
+	d.compare(0, len(x), 0, len(y))
+	return d.ops
+}
+
+type differ struct {
+	x, y []string
+	ops  []edit
+}
+
+// compare appends the edits from x[x0:x1] to y[y0:y1].
+func (d *differ) compare(x0, x1, y0, y1 int) {
+	for x0 < x1 && y0 < y1 && d.x[x0] == d.y[y0] {
+		d.ops = append(d.ops, edit{' ', x0, y0})
+		x0++
+		y0++
+	}
+	suffix := 0
+	for x0 < x1-suffix && y0 < y1-suffix && d.x[x1-suffix-1] == d.y[y1-suffix-1] {
+		suffix++
+	}
+	x1, y1 = x1-suffix, y1-suffix
+
+	switch {
+	case x0 == x1:
+		for j := y0; j < y1; j++ {
+			d.ops = append(d.ops, edit{'+', x0, j})
+		}
+	case y0 == y1:
+		for i := x0; i < x1; i++ {
+			d.ops = append(d.ops, edit{'-', i, y0})
+		}
+	default:
+		xm, ym, ok := d.middle(x0, x1, y0, y1)
+		if !ok {
+			d.compare(x0, x1, y0, y0)
+			d.compare(x1, x1, y0, y1)
+			break
+		}
+		d.compare(x0, xm, y0, ym)
+		d.compare(xm, x1, ym, y1)
+	}
+
+	for k := suffix; k > 0; k-- {
+		d.ops = append(d.ops, edit{' ', x1 + suffix - k, y1 + suffix - k})
+	}
+}
+
+// middle finds a point on a shortest edit path from x[x0:x1] to y[y0:y1] by
+// searching forward from the start and backward from the end until the two
+// searches meet.
+func (d *differ) middle(x0, x1, y0, y1 int) (int, int, bool) {
+	n, m := x1-x0, y1-y0
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	forward, backward := make([]int, 2*maxD+2), make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+
+	delta := n - m
+	odd := delta%2 != 0
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+
+	for e := 0; e < maxD; e++ {
+		for k := -e + fStart; k <= e-fEnd; k += 2 {
+			i := offset + k
+			var a int
+			if k == -e || k != e && forward[i-1] < forward[i+1] {
+				a = forward[i+1]
+			} else {
+				a = forward[i-1] + 1
+			}
+			b := a - k
+			for a < n && b < m && d.x[x0+a] == d.y[y0+b] {
+				a++
+				b++
+			}
+			forward[i] = a
+
+			switch {
+			case a > n:
+				fEnd += 2
+			case b > m:
+				fStart += 2
+			case odd:
+				j := offset + delta - k
+				if j >= 0 && j < len(backward) && backward[j] != -1 && a >= n-backward[j] {
+					return x0 + a, y0 + b, true
+				}
+			}
+		}
+
+		for k := -e + bStart; k <= e-bEnd; k += 2 {
+			i := offset + k
+			var a int
+			if k == -e || k != e && backward[i-1] < backward[i+1] {
+				a = backward[i+1]
+			} else {
+				a = backward[i-1] + 1
+			}
+			b := a - k
+			for a < n && b < m && d.x[x1-a-1] == d.y[y1-b-1] {
+				a++
+				b++
+			}
+			backward[i] = a
+
+			switch {
+			case a > n:
+				bEnd += 2
+			case b > m:
+				bStart += 2
+			case !odd:
+				j := offset + delta - k
+				if j >= 0 && j < len(forward) && forward[j] != -1 {
+					fa := forward[j]
+					fb := fa - (j - offset)
+					if fa >= n-a {
+						return x0 + fa, y0 + fb, true
+					}
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,460 @@
+// format/format.go
+
+// Package format pretty-prints Monkey source code in its canonical layout.
+//
+// Statements are put on lines of their own and indented with one tab per
+// block. Expressions are written on a single line with only the parentheses
+// needed to preserve their meaning. Comments are kept, as is a single blank
+// line where the source had one or more between two statements.
+package format
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/solbero/monkey/ast"
+	"github.com/solbero/monkey/lexer"
+	"github.com/solbero/monkey/parser"
+	"github.com/solbero/monkey/token"
+)
+
+// atom is the precedence of expressions that never need parentheses.
+const atom = parser.MEMBER + 1
+
+// Source formats a Monkey program. It fails with the parser errors if the
+// source is not a valid program.
+func Source(src string) (string, error) {
+	l := lexer.New(src)
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return "", errors.New(strings.Join(p.Errors(), "\n"))
+	}
+
+	pr := &printer{tokens: tokenize(src), comments: l.Comments()}
+	pr.statements(program.Statements, pr.tokens[len(pr.tokens)-1], 0)
+
+	return pr.out.String(), nil
+}
+
+// tokenize returns the tokens of src up to and including token.EOF.
+func tokenize(src string) []token.Token {
+	l := lexer.New(src)
+
+	tokens := []token.Token{}
+	for {
+		tok := l.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+	}
+}
+
+type printer struct {
+	out    bytes.Buffer
+	indent int
+
+	tokens   []token.Token // the tokens of the source, used to find where statements end
+	comments []token.Token // the comments not yet printed
+}
+
+func before(a, b token.Token) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+// index returns the position of tok in the token stream.
+func (p *printer) index(tok token.Token) int {
+	return sort.Search(len(p.tokens), func(i int) bool {
+		return !before(p.tokens[i], tok)
+	})
+}
+
+// closing returns the '}' matching the '{' token open.
+func (p *printer) closing(open token.Token) token.Token {
+	depth := 0
+	for _, tok := range p.tokens[p.index(open):] {
+		switch tok.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			depth--
+			if depth == 0 {
+				return tok
+			}
+		}
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+// commentBefore removes and returns the next comment if it comes before tok.
+func (p *printer) commentBefore(tok token.Token) (token.Token, bool) {
+	if len(p.comments) == 0 || !before(p.comments[0], tok) {
+		return token.Token{}, false
+	}
+	comment := p.comments[0]
+	p.comments = p.comments[1:]
+	return comment, true
+}
+
+func (p *printer) write(s string) {
+	p.out.WriteString(s)
+}
+
+func (p *printer) line(s string) {
+	p.write(strings.Repeat("\t", p.indent) + s + "\n")
+}
+
+// statements prints a list of statements closed by the token end, which is
+// the '}' of a block or the EOF of a program. Comments before end are printed
+// between the statements. last is the line the list starts on.
+func (p *printer) statements(stmts []ast.Statement, end token.Token, last int) {
+	first := true
+	separate := func(line int) {
+		if !first && line > last+1 {
+			p.write("\n")
+		}
+		first = false
+	}
+
+	for i, stmt := range stmts {
+		start := statementToken(stmt)
+		stop := end
+		if i+1 < len(stmts) {
+			stop = statementToken(stmts[i+1])
+		}
+		stmtEnd := p.tokens[p.index(stop)-1]
+
+		for comment, ok := p.commentBefore(start); ok; comment, ok = p.commentBefore(start) {
+			separate(comment.Line)
+			p.line(comment.Literal)
+			last = comment.Line
+		}
+
+		separate(start.Line)
+		p.write(strings.Repeat("\t", p.indent))
+		p.statement(stmt, i+1 < len(stmts) && p.continues(stmts[i+1]))
+		last = stmtEnd.Line
+
+		// Comments left inside the statement are moved after it, the one
+		// following it on its last line stays there.
+		inner := []token.Token{}
+		for comment, ok := p.commentBefore(stmtEnd); ok; comment, ok = p.commentBefore(stmtEnd) {
+			inner = append(inner, comment)
+		}
+		if comment, ok := p.commentBefore(stop); ok && comment.Line == stmtEnd.Line {
+			p.write(" " + comment.Literal)
+		} else if ok {
+			p.comments = append([]token.Token{comment}, p.comments...)
+		}
+		p.write("\n")
+		for _, comment := range inner {
+			p.line(comment.Literal)
+		}
+	}
+
+	for comment, ok := p.commentBefore(end); ok; comment, ok = p.commentBefore(end) {
+		separate(comment.Line)
+		p.line(comment.Literal)
+		last = comment.Line
+	}
+}
+
+// statementToken returns the first token of a statement.
+func statementToken(stmt ast.Statement) token.Token {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return stmt.Token
+	case *ast.ReturnStatement:
+		return stmt.Token
+	case *ast.StructStatement:
+		return stmt.Token
+	case *ast.ExpressionStatement:
+		return stmt.Token
+	default:
+		return token.Token{}
+	}
+}
+
+// continues reports whether stmt, as printed, starts with a token that would
+// continue the expression before it, like the '-' of -1 or the '(' of (a).
+func (p *printer) continues(stmt ast.Statement) bool {
+	inner := &printer{tokens: p.tokens}
+	inner.statement(stmt, false)
+	first := lexer.New(inner.out.String()).NextToken()
+	return parser.Precedence(first.Type) > parser.LOWEST
+}
+
+// statement prints a statement. The ';' after an if expression is left out
+// unless continues is set, as the next statement would otherwise continue the
+// if as an infix, call or index expression.
+func (p *printer) statement(stmt ast.Statement, continues bool) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		p.write(stmt.Token.Literal + " " + stmt.Name.Value + " = ")
+		p.expression(stmt.Value, parser.LOWEST)
+		p.write(";")
+	case *ast.ReturnStatement:
+		p.write("return ")
+		p.expression(stmt.ReturnValue, parser.LOWEST)
+		p.write(";")
+	case *ast.StructStatement:
+		fields := make([]string, len(stmt.Fields))
+		for i, field := range stmt.Fields {
+			fields[i] = field.Value
+		}
+		p.write("struct " + stmt.Name.Value + " { " + strings.Join(fields, ", ") + " }")
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression, parser.LOWEST)
+		if _, ok := stmt.Expression.(*ast.IfExpression); !ok || continues {
+			p.write(";")
+		}
+	}
+}
+
+// block prints a block statement. If short is set, a block holding a single
+// expression and no comments is kept on one line.
+func (p *printer) block(block *ast.BlockStatement, short bool) {
+	end := p.closing(block.Token)
+
+	if len(block.Statements) == 0 && (len(p.comments) == 0 || !before(p.comments[0], end)) {
+		p.write("{}")
+		return
+	}
+
+	if s, ok := p.inline(block, end); ok && short {
+		p.write("{ " + s + " }")
+		return
+	}
+
+	p.write("{\n")
+	p.indent++
+	p.statements(block.Statements, end, block.Token.Line)
+	p.indent--
+	p.write(strings.Repeat("\t", p.indent) + "}")
+}
+
+func (p *printer) inline(block *ast.BlockStatement, end token.Token) (string, bool) {
+	if len(block.Statements) != 1 || len(p.comments) > 0 && before(p.comments[0], end) {
+		return "", false
+	}
+	stmt, ok := block.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		return "", false
+	}
+
+	inner := &printer{tokens: p.tokens, indent: p.indent}
+	inner.expression(stmt.Expression, parser.LOWEST)
+	s := inner.out.String()
+
+	return s, !strings.Contains(s, "\n")
+}
+
+// precedence returns how tightly an expression binds, which decides whether
+// it needs parentheses as the operand of another expression.
+func precedence(exp ast.Expression) int {
+	switch exp := exp.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(exp.Token.Type)
+	case *ast.PipeExpression:
+		return parser.PIPE
+	case *ast.PrefixExpression, *ast.SpawnExpression:
+		return parser.PREFIX
+	case *ast.CallExpression:
+		return parser.CALL
+	case *ast.IndexExpression, *ast.SliceExpression:
+		return parser.INDEX
+	case *ast.MemberExpression:
+		return parser.MEMBER
+	case *ast.YieldExpression:
+		return parser.LOWEST
+	case *ast.FunctionLiteral:
+		if exp.Token.Type == token.ARROW {
+			return parser.LOWEST
+		}
+		return atom
+	default:
+		return atom
+	}
+}
+
+// expression prints exp, in parentheses if it binds less tightly than min.
+func (p *printer) expression(exp ast.Expression, min int) {
+	if precedence(exp) < min {
+		p.write("(")
+		defer p.write(")")
+	}
+
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		p.write(exp.Value)
+	case *ast.IntegerLiteral, *ast.Boolean:
+		p.write(exp.TokenLiteral())
+	case *ast.StringLiteral:
+		if exp.Token.Type == token.RAW_STRING {
+			p.write("`" + exp.Value + "`")
+		} else {
+			p.write(`"` + escape(exp.Value) + `"`)
+		}
+	case *ast.InterpolatedString:
+		p.write(`"`)
+		for _, part := range exp.Parts {
+			if str, ok := part.(*ast.StringLiteral); ok {
+				p.write(escape(str.Value))
+				continue
+			}
+			p.write("${")
+			p.expression(part, parser.LOWEST)
+			p.write("}")
+		}
+		p.write(`"`)
+	case *ast.PrefixExpression:
+		p.write(exp.Operator)
+		p.expression(exp.Right, parser.PREFIX)
+	case *ast.InfixExpression:
+		prec := parser.Precedence(exp.Token.Type)
+		p.expression(exp.Left, prec)
+		p.write(" " + exp.Operator + " ")
+		p.expression(exp.Right, prec+1)
+	case *ast.PipeExpression:
+		p.expression(exp.Left, parser.PIPE)
+		p.write(" |> ")
+		p.expression(exp.Right, parser.PIPE+1)
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(exp.Condition, parser.LOWEST)
+		p.write(") ")
+		// Both branches are kept on one line or neither is.
+		_, short := p.inline(exp.Consequence, p.closing(exp.Consequence.Token))
+		if exp.Alternative != nil && short {
+			_, short = p.inline(exp.Alternative, p.closing(exp.Alternative.Token))
+		}
+		p.block(exp.Consequence, short)
+		if exp.Alternative != nil {
+			p.write(" else ")
+			p.block(exp.Alternative, short)
+		}
+	case *ast.FunctionLiteral:
+		p.function(exp)
+	case *ast.CallExpression:
+		p.expression(exp.Function, parser.CALL)
+		p.write("(")
+		p.list(exp.Arguments)
+		p.write(")")
+	case *ast.ArrayLiteral:
+		p.write("[")
+		p.list(exp.Elements)
+		p.write("]")
+	case *ast.HashLiteral:
+		p.write("{")
+		for i, key := range exp.Keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expression(key, parser.LOWEST)
+			p.write(": ")
+			p.expression(exp.Pairs[key], parser.LOWEST)
+		}
+		p.write("}")
+	case *ast.IndexExpression:
+		p.expression(exp.Left, parser.CALL)
+		p.write("[")
+		p.expression(exp.Index, parser.LOWEST)
+		p.write("]")
+	case *ast.SliceExpression:
+		p.expression(exp.Left, parser.CALL)
+		p.write("[")
+		if exp.Start != nil {
+			p.expression(exp.Start, parser.LOWEST)
+		}
+		p.write(":")
+		if exp.End != nil {
+			p.expression(exp.End, parser.LOWEST)
+		}
+		p.write("]")
+	case *ast.MemberExpression:
+		p.expression(exp.Object, parser.CALL)
+		p.write("." + exp.Property.Value)
+	case *ast.YieldExpression:
+		p.write("yield ")
+		p.expression(exp.Value, parser.LOWEST)
+	case *ast.SpawnExpression:
+		p.write("spawn ")
+		p.expression(exp.Call, parser.LOWEST)
+	}
+}
+
+func (p *printer) list(exps []ast.Expression) {
+	for i, exp := range exps {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expression(exp, parser.LOWEST)
+	}
+}
+
+func (p *printer) function(fn *ast.FunctionLiteral) {
+	params := make([]string, len(fn.Parameters))
+	for i, param := range fn.Parameters {
+		params[i] = param.Value
+	}
+
+	if fn.Token.Type != token.ARROW {
+		p.write("fn(" + strings.Join(params, ", ") + ") ")
+		p.block(fn.Body, true)
+		return
+	}
+
+	if len(params) == 1 {
+		p.write(params[0] + " => ")
+	} else {
+		p.write("(" + strings.Join(params, ", ") + ") => ")
+	}
+
+	// The body of x => expr is a block made up by the parser from the
+	// expression, a body written as a block starts with its own '{'.
+	if fn.Body.Token.Type == token.LBRACE {
+		p.block(fn.Body, true)
+		return
+	}
+
+	body := fn.Body.Statements[0].(*ast.ExpressionStatement).Expression
+	if _, ok := body.(*ast.HashLiteral); ok {
+		p.write("(")
+		defer p.write(")")
+	}
+	p.expression(body, parser.LOWEST)
+}
+
+// escape quotes s for use between double quotes.
+func escape(s string) string {
+	var out strings.Builder
+
+	runes := []rune(s)
+	for i, ch := range runes {
+		switch {
+		case ch == '"':
+			out.WriteString(`\"`)
+		case ch == '\\':
+			out.WriteString(`\\`)
+		case ch == '\n':
+			out.WriteString(`\n`)
+		case ch == '\t':
+			out.WriteString(`\t`)
+		case ch == '\r':
+			out.WriteString(`\r`)
+		case ch == '$' && i+1 < len(runes) && runes[i+1] == '{':
+			out.WriteString(`\$`)
+		case ch < 0x20 || ch == 0x7f:
+			fmt.Fprintf(&out, `\x%02X`, ch)
+		default:
+			out.WriteRune(ch)
+		}
+	}
+
+	return out.String()
+}
@@ -0,0 +1,186 @@
+// format/format_test.go
+
+package format
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/solbero/monkey/evaluator"
+	"github.com/solbero/monkey/lexer"
+	"github.com/solbero/monkey/object"
+	"github.com/solbero/monkey/parser"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=5", "let x = 5;\n"},
+		{"const  y = x", "const y = x;\n"},
+		{"return  x", "return x;\n"},
+		{"(5 + (2 * 3))", "5 + 2 * 3;\n"},
+		{"(5 + 2) * 3", "(5 + 2) * 3;\n"},
+		{"a - (b - c)", "a - (b - c);\n"},
+		{"(a - b) - c", "a - b - c;\n"},
+		{"-(a + b)", "-(a + b);\n"},
+		{"!(-a)", "!-a;\n"},
+		{"(a + b)[0]", "(a + b)[0];\n"},
+		{"(f)(x)", "f(x);\n"},
+		{"a.b(c)[1:2]", "a.b(c)[1:2];\n"},
+		{"x |> (a |> f)", "x |> (a |> f);\n"},
+		{"(x |> f) |> g(1)", "x |> f |> g(1);\n"},
+		{"(x == 1) |> f", "x == 1 |> f;\n"},
+		{"[1,2 ,3]", "[1, 2, 3];\n"},
+		{"{}", "{};\n"},
+		{`{"a":1,"b":[2]}`, "{\"a\": 1, \"b\": [2]};\n"},
+		{"0xFF + 1_000", "0xFF + 1_000;\n"},
+		{`"a\tb\x01\"${x}\${y}"`, "\"a\\tb\\x01\\\"${x}\\${y}\";\n"},
+		{"`raw\\n`", "`raw\\n`;\n"},
+		{"struct Point{x,y}", "struct Point { x, y }\n"},
+		{"fn(){}", "fn() {};\n"},
+		{"fn(x){x}", "fn(x) { x };\n"},
+		{"fn(x){let y=x;y}", "fn(x) {\n\tlet y = x;\n\ty;\n};\n"},
+		{"(x)=>x*2", "x => x * 2;\n"},
+		{"(a,b)=>{a}", "(a, b) => { a };\n"},
+		{"() => {}", "() => {};\n"},
+		{`x => ({"a": x})`, "x => ({\"a\": x});\n"},
+		{"(x => x)(1)", "(x => x)(1);\n"},
+		{"fn(){yield 1}", "fn() { yield 1 };\n"},
+		{"spawn f(1)", "spawn f(1);\n"},
+		{"if(x){1}", "if (x) { 1 }\n"},
+		{"if(x){1}else{2}", "if (x) { 1 } else { 2 }\n"},
+		{"if (x) { 1 };\n-1;", "if (x) { 1 };\n-1;\n"},
+		{"if (x) { 1 }; [1]", "if (x) { 1 };\n[1];\n"},
+		{"if (x) { 1 } else { 2 };\n(1)", "if (x) { 1 } else { 2 }\n1;\n"},
+		{"if (x) { 1 }; let y = 1", "if (x) { 1 }\nlet y = 1;\n"},
+		{"if(x){1}else{let y=2;y}", "if (x) {\n\t1;\n} else {\n\tlet y = 2;\n\ty;\n}\n"},
+		{"let x = 1;\n\n\n\nlet y = 2;", "let x = 1;\n\nlet y = 2;\n"},
+		{"fn(){\n\n1;\n\n\n2}", "fn() {\n\t1;\n\n\t2;\n};\n"},
+		{"// a\nlet x=1// b\n// c", "// a\nlet x = 1; // b\n// c\n"},
+		{"fn(){ // a\n1}", "fn() {\n\t// a\n\t1;\n};\n"},
+		{"fn(){\n1\n// end\n}", "fn() {\n\t1;\n\t// end\n};\n"},
+		{"f(1, // a\n2)", "f(1, 2);\n// a\n"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		res, err := Source(tt.input)
+		if err != nil {
+			t.Errorf("Source(%q) failed: %s", tt.input, err)
+			continue
+		}
+		if res != tt.expected {
+			t.Errorf("Source(%q) wrong, expected %q, got %q", tt.input, tt.expected, res)
+		}
+
+		again, err := Source(res)
+		if err != nil {
+			t.Errorf("Source(%q) failed on its own output: %s", tt.input, err)
+			continue
+		}
+		if again != res {
+			t.Errorf("Source(%q) not idempotent, expected %q, got %q", tt.input, res, again)
+		}
+	}
+}
+
+// TestSourceMeaning runs programs before and after formatting them twice and
+// compares their values.
+func TestSourceMeaning(t *testing.T) {
+	tests := []string{
+		"let x = true;\nif (x) { 1 };\n-1;",
+		"let x = true;\nif (x) { 1 };\n[2][0];",
+		"let x = false;\nif (x) { 1 } else { 2 };\n(3);",
+		"let f = fn(x) { if (x) { 1 }; -1 };\nf(true)",
+		"let x = 1;\nif (x) { 1 };\n(fn() { 4 })()",
+	}
+
+	run := func(src string) string {
+		p := parser.New(lexer.New(src))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			return "parser errors: " + p.Errors()[0]
+		}
+		return evaluator.Eval(program, object.NewEnvironment()).Inspect()
+	}
+
+	for _, input := range tests {
+		once, err := Source(input)
+		if err != nil {
+			t.Errorf("Source(%q) failed: %s", input, err)
+			continue
+		}
+		twice, err := Source(once)
+		if err != nil {
+			t.Errorf("Source(%q) failed on its own output: %s", input, err)
+			continue
+		}
+
+		expected := run(input)
+		for _, formatted := range []string{once, twice} {
+			if got := run(formatted); got != expected {
+				t.Errorf("Source(%q) changed the result of the program, expected %s, got %s from %q", input, expected, got, formatted)
+			}
+		}
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	_, err := Source("let = 5;")
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	expected := "1:5: Expected next token to be IDENT, got = instead\n1:5: No prefix parse function for = found"
+	if err.Error() != expected {
+		t.Errorf("wrong error, expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected string
+	}{
+		{"a\nb\n", "a\nb\n", ""},
+		{
+			"a\nb\nc\n",
+			"a\nB\nc\n",
+			"--- f\n+++ f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			"--- f\n+++ f\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+		{"", "x", "--- f\n+++ f\n@@ -0,0 +1,1 @@\n+x\n"},
+	}
+
+	for _, tt := range tests {
+		res := Diff("f", tt.a, tt.b)
+		if res != tt.expected {
+			t.Errorf("Diff(%q, %q) wrong, expected\n%s\ngot\n%s", tt.a, tt.b, tt.expected, res)
+		}
+	}
+}
+
+func TestDiffLarge(t *testing.T) {
+	var a, b strings.Builder
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&a, "%d\n", i)
+		if i == 50000 {
+			b.WriteString("x\n")
+		} else {
+			fmt.Fprintf(&b, "%d\n", i)
+		}
+	}
+
+	expected := "--- f\n+++ f\n@@ -49998,7 +49998,7 @@\n 49997\n 49998\n 49999\n-50000\n+x\n 50001\n 50002\n 50003\n"
+	res := Diff("f", a.String(), b.String())
+	if res != expected {
+		t.Errorf("Diff wrong, expected\n%s\ngot\n%s", expected, res)
+	}
+}
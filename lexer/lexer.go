@@ -4,13 +4,17 @@ package lexer
 
 import (
 	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/solbero/monkey/token"
 )
 
 func New(input string) *Lexer {
-	l := &Lexer{input: []rune(input)}
+	l := &Lexer{input: []rune(input), line: 1}
 	l.readChar()
 	return l
 }
@@ -24,12 +28,69 @@ type Lexer struct {
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
 	ch           rune // current char
+	line         int  // line of current char
+	column       int  // column of current char
+
+	startLine   int // line of the token being read
+	startColumn int // column of the token being read
+
+	templates []int // open '{' count of each interpolation being lexed
+	errors    []Error
+	comments  []token.Token
 }
 
-func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
+// Error is a syntax error at a position in the input.
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+// String returns the message prefixed with "line:column: ".
+func (e Error) String() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// Comments returns the comments skipped so far as token.COMMENT tokens.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
+// Errors returns the errors found in the input so far.
+func (l *Lexer) Errors() []string {
+	errors := make([]string, len(l.errors))
+	for i, err := range l.errors {
+		errors[i] = err.String()
+	}
+	return errors
+}
+
+// ErrorList returns the errors with their positions.
+func (l *Lexer) ErrorList() []Error {
+	return l.errors
+}
 
+// error records an error at the given position.
+func (l *Lexer) error(line, column int, format string, a ...interface{}) {
+	l.errors = append(l.errors, Error{Line: line, Column: column, Message: fmt.Sprintf(format, a...)})
+}
+
+func (l *Lexer) NextToken() token.Token {
 	l.skipWhitespace()
+	for l.ch == '/' && l.peekChar() == '/' {
+		l.readComment()
+		l.skipWhitespace()
+	}
+
+	l.startLine, l.startColumn = l.line, l.column
+	tok := l.readToken()
+	tok.Line, tok.Column = l.startLine, l.startColumn
+
+	return tok
+}
+
+func (l *Lexer) readToken() token.Token {
+	var tok token.Token
 
 	switch l.ch {
 	case '=':
@@ -38,6 +99,11 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.EQ, Literal: literal}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.ARROW, Literal: literal}
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
@@ -62,8 +128,20 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.LT, l.ch)
 	case '>':
 		tok = newToken(token.GT, l.ch)
+	case '|':
+		if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.PIPE, Literal: literal}
+		} else {
+			l.error(l.line, l.column, "Illegal character %q", l.ch)
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		tok = newToken(token.DOT, l.ch)
 	case ';':
 		tok = newToken(token.SEMICOLON, l.ch)
 	case ',':
@@ -73,17 +151,34 @@ func (l *Lexer) NextToken() token.Token {
 	case ')':
 		tok = newToken(token.RPAREN, l.ch)
 	case '{':
+		if n := len(l.templates); n > 0 {
+			l.templates[n-1]++
+		}
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
-		tok = newToken(token.RBRACE, l.ch)
+		if n := len(l.templates); n > 0 && l.templates[n-1] == 0 {
+			l.templates = l.templates[:n-1]
+			tok = l.readString(token.TEMPLATE_END)
+		} else {
+			if n > 0 {
+				l.templates[n-1]--
+			}
+			tok = newToken(token.RBRACE, l.ch)
+		}
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch)
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok = l.readString(token.STRING)
+	case '`':
+		tok.Type = token.RAW_STRING
+		tok.Literal = l.readRawString()
 	case 0:
+		if len(l.templates) > 0 {
+			l.error(l.line, l.column, "Unterminated string literal")
+			l.templates = nil
+		}
 		tok.Literal = ""
 		tok.Type = token.EOF
 	default:
@@ -93,9 +188,10 @@ func (l *Lexer) NextToken() token.Token {
 			return tok
 		} else if isDigit(l.ch) {
 			tok.Type = token.INT
-			tok.Literal = string(l.readNumber())
+			tok.Literal = l.readNumber()
 			return tok
 		} else {
+			l.error(l.line, l.column, "Illegal character %q", l.ch)
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	}
@@ -105,6 +201,13 @@ func (l *Lexer) NextToken() token.Token {
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
+
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
@@ -131,46 +234,210 @@ func (l *Lexer) readIdentifier() []rune {
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() []rune {
+// readNumber reads an integer literal. Letters and underscores directly
+// following the digits are read as part of the literal so that malformed
+// numbers are reported as a whole.
+func (l *Lexer) readNumber() string {
 	position := l.position
-	for isDigit(l.ch) {
+	for isDigit(l.ch) || isLetter(l.ch) || l.ch == '_' {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+
+	literal := string(l.input[position:l.position])
+	if msg := checkNumber(literal); msg != "" {
+		l.error(l.startLine, l.startColumn, "%s", msg)
+	}
+
+	return literal
 }
 
-func (l *Lexer) readString() string {
+// checkNumber validates an integer literal in one of the forms 123, 0x7f,
+// 0o17 and 0b101, where '_' may separate digits. It returns a description of
+// the problem or "" if the literal is valid.
+func checkNumber(literal string) string {
+	digits, base, name := literal, 10, "decimal"
+	if len(literal) > 1 && literal[0] == '0' {
+		switch literal[1] {
+		case 'x', 'X':
+			digits, base, name = literal[2:], 16, "hexadecimal"
+		case 'o', 'O':
+			digits, base, name = literal[2:], 8, "octal"
+		case 'b', 'B':
+			digits, base, name = literal[2:], 2, "binary"
+		default:
+			if isDigit(rune(literal[1])) || literal[1] == '_' {
+				return fmt.Sprintf("Leading zero in decimal literal %s", literal)
+			}
+		}
+	}
+
+	if digits == "" {
+		return fmt.Sprintf("Missing digits in %s literal %s", name, literal)
+	}
+
+	for i, ch := range digits {
+		if ch == '_' {
+			if i == 0 || i == len(digits)-1 || digits[i-1] == '_' {
+				return fmt.Sprintf("Misplaced '_' in %s literal %s", name, literal)
+			}
+			continue
+		}
+		if digitValue(ch) >= base {
+			return fmt.Sprintf("Invalid digit %q in %s literal %s", ch, name, literal)
+		}
+	}
+
+	if _, err := strconv.ParseInt(literal, 0, 64); err != nil {
+		return fmt.Sprintf("Integer literal %s out of range", literal)
+	}
+
+	return ""
+}
+
+// digitValue returns the value of a hexadecimal digit, or 16 for any other
+// character.
+func digitValue(ch rune) int {
+	switch {
+	case '0' <= ch && ch <= '9':
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch - 'a' + 10)
+	case 'A' <= ch && ch <= 'F':
+		return int(ch - 'A' + 10)
+	default:
+		return 16
+	}
+}
+
+// readString reads a string literal, or the part of one that follows an
+// interpolation, up to the closing '"' or the next "${". The token is of type
+// end if the string is closed, and of type TEMPLATE if an interpolation
+// follows.
+func (l *Lexer) readString(end token.TokenType) token.Token {
 	buff := bytes.Buffer{}
-	l.readChar() // skip the first '"'
+	l.readChar() // skip the opening '"' or the '}' closing an interpolation
 
 	for {
-		if l.ch == '\\' { // skip the escape character
-			switch l.peekChar() {
-			case '"':
-				buff.WriteByte('"')
-			case 'n':
-				buff.WriteByte('\n')
-			case 't':
-				buff.WriteByte('\t')
-			case 'r':
-				buff.WriteByte('\r')
-			case '\\':
-				buff.WriteByte('\\')
+		switch l.ch {
+		case 0:
+			l.error(l.startLine, l.startColumn, "Unterminated string literal")
+			return token.Token{Type: end, Literal: buff.String()}
+		case '"':
+			return token.Token{Type: end, Literal: buff.String()}
+		case '$':
+			if l.peekChar() == '{' {
+				l.readChar()
+				l.templates = append(l.templates, 0)
+				return token.Token{Type: token.TEMPLATE, Literal: buff.String()}
 			}
-			l.readChar()
-			l.readChar()
-			continue
+			buff.WriteRune(l.ch)
+		case '\\':
+			l.readEscape(&buff)
+		default:
+			buff.WriteRune(l.ch)
 		}
+		l.readChar()
+	}
+}
+
+// readEscape writes the character denoted by the escape sequence starting at
+// the current backslash to buff and leaves the lexer on its last character.
+func (l *Lexer) readEscape(buff *bytes.Buffer) {
+	line, column := l.line, l.column
 
-		if l.ch == '"' || l.ch == 0 {
+	switch l.peekChar() {
+	case '"', '\\', '$':
+		l.readChar()
+		buff.WriteRune(l.ch)
+	case 'n':
+		l.readChar()
+		buff.WriteByte('\n')
+	case 't':
+		l.readChar()
+		buff.WriteByte('\t')
+	case 'r':
+		l.readChar()
+		buff.WriteByte('\r')
+	case 'x':
+		l.readChar()
+		digits := l.readHexDigits(2)
+		if len(digits) != 2 {
+			l.error(line, column, "Invalid escape sequence \\x%s, want two hexadecimal digits", digits)
+			return
+		}
+		value, _ := strconv.ParseUint(digits, 16, 8)
+		buff.WriteRune(rune(value))
+	case 'u':
+		l.readChar()
+		if l.peekChar() != '{' {
+			l.error(line, column, "Invalid escape sequence \\u, want \\u{...}")
+			return
+		}
+		l.readChar()
+		digits := l.readHexDigits(6)
+		if l.peekChar() != '}' || len(digits) == 0 {
+			l.error(line, column, "Invalid escape sequence \\u{%s, want one to six hexadecimal digits and '}'", digits)
+			return
+		}
+		l.readChar()
+		value, _ := strconv.ParseUint(digits, 16, 32)
+		if !utf8.ValidRune(rune(value)) {
+			l.error(line, column, "Invalid code point \\u{%s}", digits)
+			return
+		}
+		buff.WriteRune(rune(value))
+	case 0:
+		// the unterminated string is reported by the caller
+	default:
+		l.readChar()
+		l.error(line, column, "Unknown escape sequence \\%c", l.ch)
+		buff.WriteRune(l.ch)
+	}
+}
+
+// readHexDigits reads up to max hexadecimal digits following the current
+// character.
+func (l *Lexer) readHexDigits(max int) string {
+	digits := []rune{}
+	for len(digits) < max && isHexDigit(l.peekChar()) {
+		l.readChar()
+		digits = append(digits, l.ch)
+	}
+	return string(digits)
+}
+
+// readRawString reads a backtick delimited string. Its contents are taken
+// verbatim and may span multiple lines.
+func (l *Lexer) readRawString() string {
+	position := l.position + 1
+	for {
+		l.readChar()
+		if l.ch == '`' {
 			break
 		}
+		if l.ch == 0 {
+			l.error(l.startLine, l.startColumn, "Unterminated raw string literal")
+			break
+		}
+	}
+	return string(l.input[position:l.position])
+}
 
-		buff.WriteRune(l.ch)
+// readComment reads a comment running from "//" to the end of the line.
+func (l *Lexer) readComment() {
+	tok := token.Token{Type: token.COMMENT, Line: l.line, Column: l.column}
+
+	position := l.position
+	for l.ch != '\n' && l.ch != 0 {
 		l.readChar()
 	}
+	tok.Literal = strings.TrimRight(string(l.input[position:l.position]), " \t\r")
+
+	l.comments = append(l.comments, tok)
+}
 
-	return buff.String()
+func isHexDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9' || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
 }
 
 func isLetter(ch rune) bool {
@@ -178,7 +445,7 @@ func isLetter(ch rune) bool {
 }
 
 func isDigit(ch rune) bool {
-	return unicode.IsNumber(ch)
+	return '0' <= ch && ch <= '9'
 }
 
 func (l *Lexer) skipWhitespace() {
@@ -34,13 +34,15 @@ if (5 < 10) {
 "hello\nworld"
 "hello\t\t\tworld"
 "hello\\world"
-"hello\bworld"
+"hello\x41\u{1F600}"
 
 [1, 2];
 {"foo": "bar"};
 
 len("123")
-
+json.parse
+x |> f
+x => x
 `
 
 	tests := []struct {
@@ -126,7 +128,7 @@ len("123")
 		{token.STRING, "hello\nworld"},
 		{token.STRING, "hello\t\t\tworld"},
 		{token.STRING, "hello\\world"},
-		{token.STRING, "helloworld"},
+		{token.STRING, "helloA\U0001F600"},
 		{token.LBRACKET, "["},
 		{token.INT, "1"},
 		{token.COMMA, ","},
@@ -143,6 +145,15 @@ len("123")
 		{token.LPAREN, "("},
 		{token.STRING, "123"},
 		{token.RPAREN, ")"},
+		{token.IDENT, "json"},
+		{token.DOT, "."},
+		{token.IDENT, "parse"},
+		{token.IDENT, "x"},
+		{token.PIPE, "|>"},
+		{token.IDENT, "f"},
+		{token.IDENT, "x"},
+		{token.ARROW, "=>"},
+		{token.IDENT, "x"},
 		{token.EOF, ""},
 	}
 
@@ -160,3 +171,205 @@ len("123")
 		}
 	}
 }
+
+func TestStringTokens(t *testing.T) {
+	input := "`raw \\n ${x}\nline`" + `"a ${b} c" "${ {"k": "${v}"}["k"] }" "\${x}"`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.RAW_STRING, "raw \\n ${x}\nline"},
+		{token.TEMPLATE, "a "},
+		{token.IDENT, "b"},
+		{token.TEMPLATE_END, " c"},
+		{token.TEMPLATE, ""},
+		{token.LBRACE, "{"},
+		{token.STRING, "k"},
+		{token.COLON, ":"},
+		{token.TEMPLATE, ""},
+		{token.IDENT, "v"},
+		{token.TEMPLATE_END, ""},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "k"},
+		{token.RBRACKET, "]"},
+		{token.TEMPLATE_END, ""},
+		{token.STRING, "${x}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong, expected %q, got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong, expected %q, got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected lexer errors: %v", l.Errors())
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"abc`, "1:1: Unterminated string literal"},
+		{"`abc", "1:1: Unterminated raw string literal"},
+		{`"a ${b`, "1:7: Unterminated string literal"},
+		{`"\q"`, "1:2: Unknown escape sequence \\q"},
+		{`"\x4"`, "1:2: Invalid escape sequence \\x4, want two hexadecimal digits"},
+		{`"\u41"`, "1:2: Invalid escape sequence \\u, want \\u{...}"},
+		{`"\u{1234567}"`, "1:2: Invalid escape sequence \\u{123456, want one to six hexadecimal digits and '}'"},
+		{`"\u{D800}"`, "1:2: Invalid code point \\u{D800}"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		errors := l.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected lexer error for %s", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error for %s, expected %q, got %q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestNumberTokens(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0", "0"},
+		{"1_000_000", "1_000_000"},
+		{"0xFF", "0xFF"},
+		{"0o17", "0o17"},
+		{"0b1010_1010", "0b1010_1010"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != token.INT || tok.Literal != tt.expected {
+			t.Errorf("wrong token for %s, expected INT %q, got %s %q", tt.input, tt.expected, tok.Type, tok.Literal)
+		}
+		if len(l.Errors()) != 0 {
+			t.Errorf("unexpected lexer errors for %s: %v", tt.input, l.Errors())
+		}
+	}
+}
+
+func TestNumberErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"12abc", "1:1: Invalid digit 'a' in decimal literal 12abc"},
+		{"let x = 0xFG;", "1:9: Invalid digit 'G' in hexadecimal literal 0xFG"},
+		{"0o8", "1:1: Invalid digit '8' in octal literal 0o8"},
+		{"0b102", "1:1: Invalid digit '2' in binary literal 0b102"},
+		{"0x", "1:1: Missing digits in hexadecimal literal 0x"},
+		{"007", "1:1: Leading zero in decimal literal 007"},
+		{"1__000", "1:1: Misplaced '_' in decimal literal 1__000"},
+		{"1000_", "1:1: Misplaced '_' in decimal literal 1000_"},
+		{"0x_FF", "1:1: Misplaced '_' in hexadecimal literal 0x_FF"},
+		{"\n  99999999999999999999", "2:3: Integer literal 99999999999999999999 out of range"},
+		{"\u0662", "1:1: Illegal character '\u0662'"},
+		{"1 + \u00b2", "1:5: Illegal character '\u00b2'"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected one lexer error for %q, got %v", tt.input, errors)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error for %q, expected %q, got %q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 5;\n  x + \"a\nb\" |> f"
+
+	tests := []struct {
+		expectedType   token.TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{token.LET, 1, 1},
+		{token.IDENT, 1, 5},
+		{token.ASSIGN, 1, 7},
+		{token.INT, 1, 9},
+		{token.SEMICOLON, 1, 10},
+		{token.IDENT, 2, 3},
+		{token.PLUS, 2, 5},
+		{token.STRING, 2, 7},
+		{token.PIPE, 3, 4},
+		{token.IDENT, 3, 7},
+		{token.EOF, 3, 8},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong, expected %q, got %q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Line != tt.expectedLine || tok.Column != tt.expectedColumn {
+			t.Errorf("tests[%d] - position wrong, expected %d:%d, got %d:%d", i, tt.expectedLine, tt.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := "// first\nlet x = 5; // second  \n// third"
+
+	expectedTokens := []token.TokenType{token.LET, token.IDENT, token.ASSIGN, token.INT, token.SEMICOLON, token.EOF}
+	expectedComments := []token.Token{
+		{Type: token.COMMENT, Literal: "// first", Line: 1, Column: 1},
+		{Type: token.COMMENT, Literal: "// second", Line: 2, Column: 12},
+		{Type: token.COMMENT, Literal: "// third", Line: 3, Column: 1},
+	}
+
+	l := New(input)
+
+	for i, expected := range expectedTokens {
+		tok := l.NextToken()
+		if tok.Type != expected {
+			t.Fatalf("tests[%d] - tokentype wrong, expected %q, got %q", i, expected, tok.Type)
+		}
+	}
+
+	comments := l.Comments()
+	if len(comments) != len(expectedComments) {
+		t.Fatalf("wrong number of comments, expected %d, got %d", len(expectedComments), len(comments))
+	}
+	for i, expected := range expectedComments {
+		if comments[i] != expected {
+			t.Errorf("comments[%d] wrong, expected %+v, got %+v", i, expected, comments[i])
+		}
+	}
+}
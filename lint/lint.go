@@ -0,0 +1,324 @@
+// lint/lint.go
+
+// Package lint reports likely mistakes in Monkey programs.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/solbero/monkey/ast"
+	"github.com/solbero/monkey/evaluator"
+	"github.com/solbero/monkey/object"
+)
+
+// The rules problems are reported under.
+const (
+	RuleUnused            = "unused"
+	RuleShadowedBuiltin   = "shadowed-builtin"
+	RuleUnreachable       = "unreachable"
+	RuleUndefined         = "undefined"
+	RuleDuplicateKey      = "duplicate-key"
+	RuleArity             = "arity"
+	RuleConstantCondition = "constant-condition"
+)
+
+// Problem is a likely mistake at a position in a program.
+type Problem struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", p.Line, p.Column, p.Message, p.Rule)
+}
+
+// binding is a name introduced by let, const, struct or a parameter.
+type binding struct {
+	kind  string // "let", "const", "struct" or "parameter"
+	name  *ast.Identifier
+	value ast.Expression // the bound expression of let and const
+	arity int            // the number of fields of a struct
+	used  bool
+
+	// The position from which on the binding is in effect, the last node of
+	// its value for let and const.
+	line, column int
+}
+
+// scope is the program or the body of a function. Blocks of if expressions
+// share the scope they are in.
+type scope struct {
+	outer    *scope
+	bindings []*binding
+}
+
+// reference is an identifier referring to a binding. Calls record the number
+// of arguments passed.
+type reference struct {
+	ident *ast.Identifier
+	scope *scope
+	call  bool
+	args  int
+}
+
+type linter struct {
+	problems   []Problem
+	bindings   []*binding
+	references []reference
+	builtins   map[string]bool
+}
+
+// Check returns the problems found in program, ordered by position.
+func Check(program *ast.Program) []Problem {
+	l := &linter{builtins: make(map[string]bool)}
+	for _, name := range evaluator.BuiltinNames() {
+		l.builtins[name] = true
+	}
+
+	// Bindings are collected first, as functions may refer to bindings
+	// defined after them.
+	l.walk(program, &scope{})
+	for _, ref := range l.references {
+		l.resolve(ref)
+	}
+	for _, b := range l.bindings {
+		if !b.used && b.kind != "struct" {
+			l.report(b.name, RuleUnused, "unused %s %s", describe(b.kind), b.name.Value)
+		}
+	}
+
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		a, b := l.problems[i], l.problems[j]
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+	return l.problems
+}
+
+func describe(kind string) string {
+	switch kind {
+	case "let":
+		return "variable"
+	case "const":
+		return "constant"
+	}
+	return kind
+}
+
+// report adds a problem at the start of node, which for e.g. infix
+// expressions comes before the position of the node itself.
+func (l *linter) report(node ast.Node, rule, format string, a ...interface{}) {
+	line, column := node.Position()
+	ast.Inspect(node, func(n ast.Node) bool {
+		if l, c := n.Position(); l != 0 && (l < line || l == line && c < column) {
+			line, column = l, c
+		}
+		return true
+	})
+	l.problems = append(l.problems, Problem{Line: line, Column: column, Rule: rule, Message: fmt.Sprintf(format, a...)})
+}
+
+// walk records the bindings and references below node in sc and checks the
+// rules that need no resolved names.
+func (l *linter) walk(node ast.Node, sc *scope) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.Program:
+			l.unreachable(node.Statements)
+		case *ast.BlockStatement:
+			l.unreachable(node.Statements)
+		case *ast.LetStatement:
+			kind := "let"
+			if node.IsConst() {
+				kind = "const"
+			}
+			b := &binding{kind: kind, name: node.Name, value: node.Value}
+			if node.Value != nil {
+				b.line, b.column = last(node.Value)
+			}
+			l.define(sc, b)
+			l.walk(node.Value, sc)
+			return false
+		case *ast.StructStatement:
+			l.define(sc, &binding{kind: "struct", name: node.Name, arity: len(node.Fields)})
+			return false
+		case *ast.FunctionLiteral:
+			inner := &scope{outer: sc}
+			for _, param := range node.Parameters {
+				l.define(inner, &binding{kind: "parameter", name: param})
+			}
+			l.walk(node.Body, inner)
+			return false
+		case *ast.MemberExpression:
+			// The property is a name, not a reference to a binding.
+			l.walk(node.Object, sc)
+			return false
+		case *ast.CallExpression:
+			return l.call(node.Function, node.Arguments, 0, sc)
+		case *ast.PipeExpression:
+			l.walk(node.Left, sc)
+			if call, ok := node.Right.(*ast.CallExpression); ok {
+				l.call(call.Function, call.Arguments, 1, sc)
+				return false
+			}
+			l.call(node.Right, nil, 1, sc)
+			return false
+		case *ast.Identifier:
+			l.references = append(l.references, reference{ident: node, scope: sc})
+		case *ast.HashLiteral:
+			l.duplicateKeys(node)
+		case *ast.IfExpression:
+			if val, ok := evaluator.Constant(node.Condition); ok {
+				always := "true"
+				if val == evaluator.NULL || val == evaluator.FALSE {
+					always = "false"
+				}
+				l.report(node, RuleConstantCondition, "condition is always %s", always)
+			}
+		}
+		return true
+	})
+}
+
+// call records a call of function with args and extra arguments passed by a
+// pipe. It reports whether the children of the call still need walking.
+func (l *linter) call(function ast.Expression, args []ast.Expression, extra int, sc *scope) bool {
+	ident, ok := function.(*ast.Identifier)
+	if !ok {
+		if extra == 0 {
+			return true
+		}
+		l.walk(function, sc)
+	} else {
+		l.references = append(l.references, reference{ident: ident, scope: sc, call: true, args: len(args) + extra})
+	}
+
+	for _, arg := range args {
+		l.walk(arg, sc)
+	}
+	return false
+}
+
+func (l *linter) define(sc *scope, b *binding) {
+	if b.name == nil {
+		return
+	}
+	if b.line == 0 {
+		b.line, b.column = b.name.Position()
+	}
+	if l.builtins[b.name.Value] {
+		l.report(b.name, RuleShadowedBuiltin, "%s %s shadows a builtin", describe(b.kind), b.name.Value)
+	}
+	sc.bindings = append(sc.bindings, b)
+	l.bindings = append(l.bindings, b)
+}
+
+// resolve marks the binding ref refers to as used and checks calls of it.
+func (l *linter) resolve(ref reference) {
+	b := lookup(ref.scope, ref.ident)
+	if b == nil {
+		if ref.call && !l.builtins[ref.ident.Value] {
+			l.report(ref.ident, RuleUndefined, "call of undefined function %s", ref.ident.Value)
+		}
+		return
+	}
+
+	b.used = true
+	if !ref.call {
+		return
+	}
+
+	switch fn := b.value.(type) {
+	case *ast.FunctionLiteral:
+		if len(fn.Parameters) != ref.args {
+			l.report(ref.ident, RuleArity, "%s takes %s, got %d", ref.ident.Value, plural(len(fn.Parameters), "argument"), ref.args)
+		}
+	default:
+		if b.kind == "struct" && b.arity != ref.args {
+			l.report(ref.ident, RuleArity, "struct %s has %s, got %d", ref.ident.Value, plural(b.arity, "field"), ref.args)
+		}
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// lookup finds the binding an identifier refers to: the last one in effect
+// before it in the nearest scope that has one. The first binding after the
+// use is taken if there is none before, as a function may refer to bindings
+// that only exist by the time it is called.
+func lookup(sc *scope, ident *ast.Identifier) *binding {
+	line, column := ident.Position()
+	for ; sc != nil; sc = sc.outer {
+		var before, after *binding
+		for _, b := range sc.bindings {
+			switch {
+			case b.name.Value != ident.Value:
+			case b.line < line || b.line == line && b.column < column:
+				before = b
+			case after == nil:
+				after = b
+			}
+		}
+		if before != nil {
+			return before
+		}
+		if after != nil {
+			return after
+		}
+	}
+	return nil
+}
+
+// last returns the position of the last node below node.
+func last(node ast.Node) (int, int) {
+	line, column := node.Position()
+	ast.Inspect(node, func(n ast.Node) bool {
+		if l, c := n.Position(); l > line || l == line && c > column {
+			line, column = l, c
+		}
+		return true
+	})
+	return line, column
+}
+
+// unreachable reports the statement following a return statement.
+func (l *linter) unreachable(stmts []ast.Statement) {
+	for i, stmt := range stmts[:max(len(stmts)-1, 0)] {
+		if _, ok := stmt.(*ast.ReturnStatement); ok {
+			l.report(stmts[i+1], RuleUnreachable, "unreachable code after return")
+			return
+		}
+	}
+}
+
+// duplicateKeys reports constant keys of a hash literal that repeat an
+// earlier key.
+func (l *linter) duplicateKeys(hash *ast.HashLiteral) {
+	seen := map[object.HashKey]bool{}
+	for _, key := range hash.Keys {
+		val, ok := evaluator.Constant(key)
+		if !ok {
+			continue
+		}
+		hashKey, ok := object.HashKeyOf(val)
+		if !ok {
+			continue
+		}
+		if seen[hashKey] {
+			display := val.Inspect()
+			if val.Type() == object.STRING_OBJ {
+				display = strconv.Quote(display)
+			}
+			l.report(key, RuleDuplicateKey, "duplicate key %s in hash literal", display)
+		}
+		seen[hashKey] = true
+	}
+}
@@ -0,0 +1,63 @@
+// lint/lint_test.go
+
+package lint
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/solbero/monkey/lexer"
+	"github.com/solbero/monkey/parser"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; x", nil},
+		{"let x = 1;", []string{"1:5: unused variable x (unused)"}},
+		{"const x = 1;", []string{"1:7: unused constant x (unused)"}},
+		{"let f = fn(a, b) { a }; f(1, 2)", []string{"1:15: unused parameter b (unused)"}},
+		{"let x = 1; let x = x + 1; x", nil},
+		{"let f = fn() { g() }; let g = fn() { 1 }; f()", nil},
+		{"let p = {}; p.x", nil},
+		{"let len = 1; len", []string{"1:5: variable len shadows a builtin (shadowed-builtin)"}},
+		{"let f = fn(puts) { puts }; f(1)", []string{"1:12: parameter puts shadows a builtin (shadowed-builtin)"}},
+		{"let f = fn() { return 1; 2 }; f()", []string{"1:26: unreachable code after return (unreachable)"}},
+		{"return 1; 2; 3", []string{"1:11: unreachable code after return (unreachable)"}},
+		{"foo(1)", []string{"1:1: call of undefined function foo (undefined)"}},
+		{"1 |> foo", []string{"1:6: call of undefined function foo (undefined)"}},
+		{"len([1]) + x", nil},
+		{`{"a": 1, "b": 2, "a": 3}`, []string{`1:18: duplicate key "a" in hash literal (duplicate-key)`}},
+		{"{1: 1, 3 - 2: 2, true: 3, !false: 4}", []string{
+			"1:8: duplicate key 1 in hash literal (duplicate-key)",
+			"1:27: duplicate key true in hash literal (duplicate-key)",
+		}},
+		{"let k = 1; {k: 1, k: 2}", nil},
+		{"let f = fn(a, b) { a + b }; f(1)", []string{"1:29: f takes 2 arguments, got 1 (arity)"}},
+		{"let f = fn(a) { a }; f(1, 2)", []string{"1:22: f takes 1 argument, got 2 (arity)"}},
+		{"let f = fn(a, b) { a + b }; 1 |> f(2); 1 |> f", []string{"1:45: f takes 2 arguments, got 1 (arity)"}},
+		{"struct P { x, y } P(1)", []string{"1:19: struct P has 2 fields, got 1 (arity)"}},
+		{"let f = fn(a) { a }; let g = f; g(1, 2)", nil},
+		{"if (true) { 1 }", []string{"1:1: condition is always true (constant-condition)"}},
+		{"if (1 > 2) { 1 }", []string{"1:1: condition is always false (constant-condition)"}},
+		{"let x = 1; if (x > 2) { 1 }", nil},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("%s: parser errors %v", tt.input, p.Errors())
+		}
+
+		var problems []string
+		for _, problem := range Check(program) {
+			problems = append(problems, problem.String())
+		}
+		if !reflect.DeepEqual(problems, tt.expected) {
+			t.Errorf("%s: wrong problems\ngot  %q\nwant %q", tt.input, problems, tt.expected)
+		}
+	}
+}
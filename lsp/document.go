@@ -0,0 +1,297 @@
+// lsp/document.go
+
+package lsp
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/solbero/monkey/ast"
+	"github.com/solbero/monkey/lexer"
+	"github.com/solbero/monkey/parser"
+	"github.com/solbero/monkey/token"
+)
+
+// document is an open text document and what is statically known about it.
+type document struct {
+	uri     string
+	lines   []string
+	tokens  []token.Token // without comments
+	program *ast.Program
+	errors  []lexer.Error
+
+	root   *scope
+	idents []*ast.Identifier               // identifiers in source order
+	defs   map[*ast.Identifier]*definition // what each identifier refers to
+}
+
+type definitionKind int
+
+const (
+	defLet definitionKind = iota
+	defConst
+	defParameter
+	defStruct
+)
+
+// definition is a binding introduced by let, const, struct or a parameter.
+type definition struct {
+	kind  definitionKind
+	name  *ast.Identifier
+	node  ast.Statement  // the defining statement, nil for parameters
+	value ast.Expression // the bound expression of let and const
+}
+
+// scope is the program or the body of a function. Blocks of if expressions
+// share the scope they are in.
+type scope struct {
+	outer    *scope
+	fn       *ast.FunctionLiteral // nil for the program
+	defs     []*definition        // in source order
+	children []*scope
+}
+
+func newDocument(uri, text string) *document {
+	d := &document{
+		uri:   uri,
+		lines: strings.Split(text, "\n"),
+		root:  &scope{},
+		defs:  make(map[*ast.Identifier]*definition),
+	}
+
+	l := lexer.New(text)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		d.tokens = append(d.tokens, tok)
+	}
+
+	p := parser.New(lexer.New(text))
+	d.program = p.ParseProgram()
+	d.errors = p.ErrorList()
+
+	// Definitions are collected first, as functions may refer to bindings
+	// defined after them.
+	uses := map[*ast.Identifier]*scope{}
+	d.collect(d.program, d.root, uses)
+	for ident, sc := range uses {
+		if def := resolve(sc, ident); def != nil {
+			d.defs[ident] = def
+		}
+	}
+	sort.Slice(d.idents, func(i, j int) bool {
+		return before(d.idents[i].Token, d.idents[j].Token)
+	})
+
+	return d
+}
+
+// collect records the definitions below node in sc and the scope of each
+// identifier that refers to a binding.
+func (d *document) collect(node ast.Node, sc *scope, uses map[*ast.Identifier]*scope) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			kind := defLet
+			if node.IsConst() {
+				kind = defConst
+			}
+			d.define(sc, &definition{kind: kind, name: node.Name, node: node, value: node.Value})
+			d.collect(node.Value, sc, uses)
+			return false
+		case *ast.StructStatement:
+			d.define(sc, &definition{kind: defStruct, name: node.Name, node: node})
+			return false
+		case *ast.FunctionLiteral:
+			inner := &scope{outer: sc, fn: node}
+			sc.children = append(sc.children, inner)
+			for _, param := range node.Parameters {
+				d.define(inner, &definition{kind: defParameter, name: param})
+			}
+			d.collect(node.Body, inner, uses)
+			return false
+		case *ast.MemberExpression:
+			// The property is a name, not a reference to a binding.
+			d.collect(node.Object, sc, uses)
+			return false
+		case *ast.Identifier:
+			d.idents = append(d.idents, node)
+			uses[node] = sc
+		}
+		return true
+	})
+}
+
+func (d *document) define(sc *scope, def *definition) {
+	if def.name == nil {
+		return
+	}
+	sc.defs = append(sc.defs, def)
+	d.idents = append(d.idents, def.name)
+	d.defs[def.name] = def
+}
+
+// child returns the scope of the body of fn.
+func (sc *scope) child(fn *ast.FunctionLiteral) *scope {
+	for _, child := range sc.children {
+		if child.fn == fn {
+			return child
+		}
+	}
+	return nil
+}
+
+// resolve finds the definition an identifier refers to: the last one before
+// it in the nearest scope that has one. A binding defined after the use is
+// taken if there is none before, as a function may refer to bindings that
+// only exist by the time it is called.
+func resolve(sc *scope, ident *ast.Identifier) *definition {
+	for ; sc != nil; sc = sc.outer {
+		var found *definition
+		for _, def := range sc.defs {
+			if def.name.Value != ident.Value {
+				continue
+			}
+			if found == nil || before(def.name.Token, ident.Token) {
+				found = def
+			}
+		}
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// identAt returns the identifier at pos, which may also be just after it.
+func (d *document) identAt(pos Position) *ast.Identifier {
+	line, column := d.location(pos)
+	for _, ident := range d.idents {
+		start := ident.Token.Column
+		if ident.Token.Line == line && start <= column && column <= start+utf8.RuneCountInString(ident.Value) {
+			return ident
+		}
+	}
+	return nil
+}
+
+// visible returns the definitions visible at pos, inner ones first.
+func (d *document) visible(pos Position) []*definition {
+	line, column := d.location(pos)
+	at := token.Token{Line: line, Column: column}
+
+	sc := d.root
+	for inner := sc; inner != nil; {
+		sc, inner = inner, nil
+		for _, child := range sc.children {
+			if !before(at, child.fn.Body.Token) && before(at, d.end(child.fn.Body)) {
+				inner = child
+			}
+		}
+	}
+
+	var defs []*definition
+	for ; sc != nil; sc = sc.outer {
+		defs = append(defs, sc.defs...)
+	}
+	return defs
+}
+
+// end returns the position after the last character of node: the end of its
+// last token, of the brackets opened before it and, for statements, of a
+// semicolon following it.
+func (d *document) end(node ast.Node) token.Token {
+	first, last := len(d.tokens), -1
+	ast.Inspect(node, func(n ast.Node) bool {
+		if line, column := n.Position(); line != 0 {
+			i := d.index(token.Token{Line: line, Column: column})
+			first, last = min(first, i), max(last, i)
+		}
+		return true
+	})
+	if last < 0 {
+		return token.Token{}
+	}
+
+	depth := 0
+	for i := first; i < len(d.tokens); i++ {
+		switch d.tokens[i].Type {
+		case token.LPAREN, token.LBRACE, token.LBRACKET:
+			depth++
+		case token.RPAREN, token.RBRACE, token.RBRACKET:
+			depth--
+		}
+		if i >= last && depth <= 0 {
+			last = i
+			break
+		}
+	}
+
+	if _, ok := node.(ast.Statement); ok && last+1 < len(d.tokens) && d.tokens[last+1].Type == token.SEMICOLON {
+		last++
+	}
+	return tokenEnd(d.tokens[last])
+}
+
+// index returns the index of the token at the position of tok.
+func (d *document) index(tok token.Token) int {
+	i := sort.Search(len(d.tokens), func(i int) bool { return !before(d.tokens[i], tok) })
+	if i == len(d.tokens) {
+		return len(d.tokens) - 1
+	}
+	return i
+}
+
+// before reports whether token a starts before token b.
+func before(a, b token.Token) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
+
+// tokenEnd returns the position after the last character of tok. Strings
+// are taken to have no escapes.
+func tokenEnd(tok token.Token) token.Token {
+	length := utf8.RuneCountInString(tok.Literal)
+	switch tok.Type {
+	case token.STRING, token.RAW_STRING:
+		length += 2
+	}
+	return token.Token{Line: tok.Line, Column: tok.Column + length}
+}
+
+// position converts a one-based line and column in runes into an LSP
+// position.
+func (d *document) position(line, column int) Position {
+	if line < 1 || line > len(d.lines) {
+		return Position{Line: max(line-1, 0)}
+	}
+
+	runes := []rune(d.lines[line-1])
+	if column-1 > len(runes) {
+		column = len(runes) + 1
+	}
+	return Position{Line: line - 1, Character: len(utf16.Encode(runes[:max(column-1, 0)]))}
+}
+
+// location converts an LSP position into a one-based line and column in
+// runes.
+func (d *document) location(pos Position) (int, int) {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return pos.Line + 1, pos.Character + 1
+	}
+
+	units := 0
+	column := 1
+	for _, r := range d.lines[pos.Line] {
+		if units >= pos.Character {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		column++
+	}
+	return pos.Line + 1, column
+}
+
+// rangeOf returns the range from the start of a token to the end of another.
+func (d *document) rangeOf(start, end token.Token) Range {
+	return Range{Start: d.position(start.Line, start.Column), End: d.position(end.Line, end.Column)}
+}
@@ -0,0 +1,275 @@
+// lsp/features.go
+
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/solbero/monkey/ast"
+	"github.com/solbero/monkey/evaluator"
+	"github.com/solbero/monkey/format"
+	"github.com/solbero/monkey/object"
+	"github.com/solbero/monkey/token"
+)
+
+// diagnostics returns the syntax errors of the document.
+func (d *document) diagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, err := range d.errors {
+		at := token.Token{Line: err.Line, Column: err.Column}
+		end := at
+		if len(d.tokens) > 0 {
+			if tok := d.tokens[d.index(at)]; tok.Line == at.Line && tok.Column == at.Column {
+				end = tokenEnd(tok)
+			}
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    d.rangeOf(at, end),
+			Severity: SeverityError,
+			Source:   "monkey",
+			Message:  err.Message,
+		})
+	}
+	return diagnostics
+}
+
+// lookup returns the open document with the given URI.
+func (s *server) lookup(uri string) (*document, error) {
+	d, ok := s.docs[uri]
+	if !ok {
+		return nil, &rpcError{codeInvalidParams, "unknown document " + uri}
+	}
+	return d, nil
+}
+
+func (s *server) hover(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.lookup(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	ident := d.identAt(p.Position)
+	if ident == nil {
+		return nil, nil
+	}
+
+	var text string
+	switch def := d.defs[ident]; {
+	case def == nil && isBuiltin(ident.Value):
+		text = "builtin " + ident.Value
+	case def == nil:
+		return nil, nil
+	case def.kind == defParameter:
+		text = "parameter " + ident.Value
+	case def.kind == defStruct:
+		text = def.node.String()
+	default:
+		text = d.describe(def)
+	}
+
+	r := d.rangeOf(ident.Token, tokenEnd(ident.Token))
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```monkey\n" + text + "\n```"},
+		Range:    &r,
+	}, nil
+}
+
+// describe returns the declaration of a let or const binding with its type
+// and value where they are known without running the program.
+func (d *document) describe(def *definition) string {
+	keyword := "let"
+	if def.kind == defConst {
+		keyword = "const"
+	}
+	text := keyword + " " + def.name.Value
+
+	value := d.staticValue(def)
+	switch value := value.(type) {
+	case *ast.FunctionLiteral:
+		params := []string{}
+		for _, param := range value.Parameters {
+			params = append(params, param.Value)
+		}
+		return fmt.Sprintf("%s: %s = fn(%s)", text, object.FUNCTION_OBJ, strings.Join(params, ", "))
+	case ast.Expression:
+		obj, _ := evaluator.Constant(value)
+		inspect := obj.Inspect()
+		if obj.Type() == object.STRING_OBJ {
+			inspect = strconv.Quote(inspect)
+		}
+		return fmt.Sprintf("%s: %s = %s", text, obj.Type(), object.Summarize(inspect))
+	}
+	return text
+}
+
+// staticValue returns the expression bound by a let or const, following
+// bindings to other bindings, if it is a function literal or can be
+// evaluated without an environment.
+func (d *document) staticValue(def *definition) ast.Expression {
+	seen := map[*definition]bool{}
+	for def != nil && !seen[def] && (def.kind == defLet || def.kind == defConst) {
+		seen[def] = true
+		switch value := def.value.(type) {
+		case *ast.Identifier:
+			def = d.defs[value]
+		case *ast.FunctionLiteral:
+			return value
+		default:
+			if _, ok := evaluator.Constant(value); ok {
+				return value
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func isBuiltin(name string) bool {
+	for _, builtin := range evaluator.BuiltinNames() {
+		if builtin == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *server) definition(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.lookup(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	ident := d.identAt(p.Position)
+	if ident == nil || d.defs[ident] == nil {
+		return nil, nil
+	}
+
+	name := d.defs[ident].name
+	return Location{URI: d.uri, Range: d.rangeOf(name.Token, tokenEnd(name.Token))}, nil
+}
+
+func (s *server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p DocumentSymbolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.lookup(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.symbols(d.root), nil
+}
+
+// symbols returns the bindings defined by statements in sc. Functions bound
+// by let or const list the bindings in their body as children.
+func (d *document) symbols(sc *scope) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, def := range sc.defs {
+		if def.node == nil {
+			continue
+		}
+
+		line, column := def.node.Position()
+		symbol := DocumentSymbol{
+			Name:           def.name.Value,
+			Kind:           SymbolVariable,
+			Range:          d.rangeOf(token.Token{Line: line, Column: column}, d.end(def.node)),
+			SelectionRange: d.rangeOf(def.name.Token, tokenEnd(def.name.Token)),
+		}
+		switch def.kind {
+		case defConst:
+			symbol.Kind = SymbolConstant
+		case defStruct:
+			symbol.Kind = SymbolStruct
+		}
+		if fn, ok := def.value.(*ast.FunctionLiteral); ok {
+			symbol.Kind = SymbolFunction
+			if children := d.symbols(sc.child(fn)); len(children) > 0 {
+				symbol.Children = children
+			}
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+func (s *server) completion(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.lookup(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+	for _, def := range d.visible(p.Position) {
+		if seen[def.name.Value] {
+			continue
+		}
+		seen[def.name.Value] = true
+
+		item := CompletionItem{Label: def.name.Value, Kind: CompletionVariable}
+		switch {
+		case def.kind == defStruct:
+			item.Kind = CompletionStruct
+		case def.kind == defParameter:
+			item.Detail = "parameter"
+		case def.kind == defConst:
+			item.Kind = CompletionConstant
+		}
+		if _, ok := def.value.(*ast.FunctionLiteral); ok {
+			item.Kind = CompletionFunction
+		}
+		items = append(items, item)
+	}
+	for _, name := range evaluator.BuiltinNames() {
+		if !seen[name] {
+			items = append(items, CompletionItem{Label: name, Kind: CompletionFunction, Detail: "builtin"})
+		}
+	}
+	for _, keyword := range token.Keywords() {
+		items = append(items, CompletionItem{Label: keyword, Kind: CompletionKeyword})
+	}
+	return items, nil
+}
+
+func (s *server) formatting(params json.RawMessage) (interface{}, error) {
+	var p DocumentFormattingParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+	d, err := s.lookup(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.Join(d.lines, "\n")
+	formatted, err := format.Source(text)
+	if err != nil {
+		// A program with syntax errors is left as it is.
+		return nil, nil
+	}
+	if formatted == text {
+		return []TextEdit{}, nil
+	}
+
+	last := len(d.lines)
+	end := d.position(last, utf8.RuneCountInString(d.lines[last-1])+1)
+	return []TextEdit{{Range: Range{End: end}, NewText: formatted}}, nil
+}
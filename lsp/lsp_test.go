@@ -0,0 +1,387 @@
+// lsp/lsp_test.go
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+)
+
+const uri = "file:///test.mk"
+
+const source = `let x = 5;
+let y = x;
+const name = "monkey";
+struct Point { x, y }
+let add = fn(a, b) {
+  let sum = a + b;
+  sum
+};
+add(x, len(name))
+`
+
+// client talks to a server running in the same process.
+type client struct {
+	t    *testing.T
+	in   *io.PipeWriter
+	out  *bufio.Reader
+	id   int
+	done chan error
+}
+
+func newClient(t *testing.T) *client {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &client{t: t, in: inW, out: bufio.NewReader(outR), done: make(chan error, 1)}
+
+	go func() {
+		err := Serve(inR, outW)
+		outW.Close()
+		c.done <- err
+	}()
+
+	return c
+}
+
+// start initializes the server and opens a document with the given text.
+func (c *client) start(text string) PublishDiagnosticsParams {
+	if _, err := c.call("initialize", map[string]interface{}{}, nil); err != nil {
+		c.t.Fatalf("initialize failed: %s", err.Message)
+	}
+	c.notify("initialized", map[string]interface{}{})
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "monkey", Version: 1, Text: text},
+	})
+	return c.diagnostics()
+}
+
+// stop shuts the server down and returns the error Serve returned.
+func (c *client) stop() error {
+	c.call("shutdown", nil, nil)
+	c.notify("exit", nil)
+	c.in.Close()
+	return <-c.done
+}
+
+func (c *client) send(v interface{}) {
+	if err := writeMessage(c.in, v); err != nil {
+		c.t.Fatalf("write failed: %s", err)
+	}
+}
+
+func (c *client) read() message {
+	content, err := readMessage(c.out)
+	if err != nil {
+		c.t.Fatalf("read failed: %s", err)
+	}
+	var msg message
+	if err := json.Unmarshal(content, &msg); err != nil {
+		c.t.Fatalf("invalid message %s: %s", content, err)
+	}
+	return msg
+}
+
+// call sends a request and decodes the result of its response into result.
+func (c *client) call(method string, params, result interface{}) (json.RawMessage, *rpcError) {
+	c.id++
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "id": c.id, "method": method, "params": params})
+
+	msg := c.read()
+	if msg.ID == nil || string(*msg.ID) != string(mustMarshal(c.t, c.id)) {
+		c.t.Fatalf("expected response to %s, got %+v", method, msg)
+	}
+	if msg.Error != nil {
+		return nil, msg.Error
+	}
+	if result != nil {
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			c.t.Fatalf("invalid result of %s %s: %s", method, msg.Result, err)
+		}
+	}
+	return msg.Result, nil
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (c *client) diagnostics() PublishDiagnosticsParams {
+	msg := c.read()
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("expected diagnostics, got %+v", msg)
+	}
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		c.t.Fatalf("invalid diagnostics %s: %s", msg.Params, err)
+	}
+	return params
+}
+
+func mustMarshal(t *testing.T, v interface{}) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func position(line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func span(line, start, end int) Range {
+	return Range{Start: Position{Line: line, Character: start}, End: Position{Line: line, Character: end}}
+}
+
+func TestLifecycle(t *testing.T) {
+	c := newClient(t)
+
+	if _, err := c.call("textDocument/hover", position(0, 0), nil); err == nil || err.Code != codeServerNotInitialized {
+		t.Errorf("expected server not initialized error, got %v", err)
+	}
+
+	var result InitializeResult
+	if _, err := c.call("initialize", map[string]interface{}{}, &result); err != nil {
+		t.Fatalf("initialize failed: %s", err.Message)
+	}
+	if result.Capabilities.TextDocumentSync != SyncFull || !result.Capabilities.HoverProvider ||
+		!result.Capabilities.DocumentFormattingProvider {
+		t.Errorf("unexpected capabilities %+v", result.Capabilities)
+	}
+
+	if _, err := c.call("textDocument/rename", position(0, 0), nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("expected method not found error, got %v", err)
+	}
+	if _, err := c.call("textDocument/hover", position(0, 0), nil); err == nil || err.Code != codeInvalidParams {
+		t.Errorf("expected invalid params error for unknown document, got %v", err)
+	}
+
+	if err := c.stop(); err != nil {
+		t.Errorf("expected clean exit, got %s", err)
+	}
+
+	c = newClient(t)
+	c.notify("exit", nil)
+	if err := <-c.done; err == nil {
+		t.Errorf("expected error on exit without shutdown")
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t)
+
+	got := c.start("let x = 1;\nlet y = ;")
+	want := []Diagnostic{{
+		Range:    span(1, 8, 9),
+		Severity: SeverityError,
+		Source:   "monkey",
+		Message:  "No prefix parse function for ; found",
+	}}
+	if got.URI != uri || !reflect.DeepEqual(got.Diagnostics, want) {
+		t.Errorf("wrong diagnostics, got %+v, want %+v", got.Diagnostics, want)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: uri},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let x = 1;\nlet y = 2;"}},
+	})
+	if got := c.diagnostics(); len(got.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", got.Diagnostics)
+	}
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+	if got := c.diagnostics(); len(got.Diagnostics) != 0 {
+		t.Errorf("expected diagnostics to be cleared, got %+v", got.Diagnostics)
+	}
+
+	if err := c.stop(); err != nil {
+		t.Errorf("expected clean exit, got %s", err)
+	}
+}
+
+func TestHover(t *testing.T) {
+	tests := []struct {
+		line, character int
+		expected        string // "" for no hover
+	}{
+		{0, 4, "let x: INTEGER = 5"},
+		{1, 4, "let y: INTEGER = 5"},
+		{1, 8, "let x: INTEGER = 5"},
+		{2, 7, `const name: STRING = "monkey"`},
+		{3, 7, "struct Point { x, y }"},
+		{4, 4, "let add: FUNCTION = fn(a, b)"},
+		{5, 12, "parameter a"},
+		{5, 6, "let sum"},
+		{8, 8, "builtin len"},
+		{8, 1, "let add: FUNCTION = fn(a, b)"},
+		{5, 0, ""},
+		{3, 15, ""},
+	}
+
+	c := newClient(t)
+	c.start(source)
+
+	for _, tt := range tests {
+		var hover *Hover
+		if _, err := c.call("textDocument/hover", position(tt.line, tt.character), &hover); err != nil {
+			t.Fatalf("hover failed: %s", err.Message)
+		}
+
+		switch {
+		case tt.expected == "" && hover != nil:
+			t.Errorf("%d:%d: expected no hover, got %q", tt.line, tt.character, hover.Contents.Value)
+		case tt.expected != "" && hover == nil:
+			t.Errorf("%d:%d: expected hover %q, got none", tt.line, tt.character, tt.expected)
+		case tt.expected != "" && hover.Contents.Value != "```monkey\n"+tt.expected+"\n```":
+			t.Errorf("%d:%d: wrong hover, got %q, want %q", tt.line, tt.character, hover.Contents.Value, tt.expected)
+		}
+	}
+
+	c.stop()
+}
+
+func TestDefinition(t *testing.T) {
+	tests := []struct {
+		line, character int
+		expected        *Range
+	}{
+		{8, 5, &Range{Start: Position{0, 4}, End: Position{0, 5}}},
+		{1, 8, &Range{Start: Position{0, 4}, End: Position{0, 5}}},
+		{6, 2, &Range{Start: Position{5, 6}, End: Position{5, 9}}},
+		{5, 16, &Range{Start: Position{4, 16}, End: Position{4, 17}}},
+		{8, 0, &Range{Start: Position{4, 4}, End: Position{4, 7}}},
+		{8, 8, nil},
+		{0, 8, nil},
+	}
+
+	c := newClient(t)
+	c.start(source)
+
+	for _, tt := range tests {
+		var location *Location
+		if _, err := c.call("textDocument/definition", position(tt.line, tt.character), &location); err != nil {
+			t.Fatalf("definition failed: %s", err.Message)
+		}
+
+		switch {
+		case tt.expected == nil && location != nil:
+			t.Errorf("%d:%d: expected no definition, got %+v", tt.line, tt.character, location)
+		case tt.expected != nil && location == nil:
+			t.Errorf("%d:%d: expected definition at %+v, got none", tt.line, tt.character, *tt.expected)
+		case tt.expected != nil && (location.URI != uri || location.Range != *tt.expected):
+			t.Errorf("%d:%d: wrong definition, got %+v, want %+v", tt.line, tt.character, *location, *tt.expected)
+		}
+	}
+
+	c.stop()
+}
+
+func TestDocumentSymbol(t *testing.T) {
+	c := newClient(t)
+	c.start(source)
+
+	var symbols []DocumentSymbol
+	if _, err := c.call("textDocument/documentSymbol", DocumentSymbolParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &symbols); err != nil {
+		t.Fatalf("documentSymbol failed: %s", err.Message)
+	}
+
+	want := []DocumentSymbol{
+		{Name: "x", Kind: SymbolVariable, Range: span(0, 0, 10), SelectionRange: span(0, 4, 5)},
+		{Name: "y", Kind: SymbolVariable, Range: span(1, 0, 10), SelectionRange: span(1, 4, 5)},
+		{Name: "name", Kind: SymbolConstant, Range: span(2, 0, 22), SelectionRange: span(2, 6, 10)},
+		{Name: "Point", Kind: SymbolStruct, Range: span(3, 0, 21), SelectionRange: span(3, 7, 12)},
+		{
+			Name:           "add",
+			Kind:           SymbolFunction,
+			Range:          Range{Start: Position{4, 0}, End: Position{7, 2}},
+			SelectionRange: span(4, 4, 7),
+			Children: []DocumentSymbol{
+				{Name: "sum", Kind: SymbolVariable, Range: span(5, 2, 18), SelectionRange: span(5, 6, 9)},
+			},
+		},
+	}
+	if !reflect.DeepEqual(symbols, want) {
+		t.Errorf("wrong symbols\ngot  %+v\nwant %+v", symbols, want)
+	}
+
+	c.stop()
+}
+
+func TestCompletion(t *testing.T) {
+	tests := []struct {
+		line, character int
+		present         []string
+		absent          []string
+	}{
+		{6, 2, []string{"sum", "a", "b", "add", "x", "Point", "len", "let", "fn"}, nil},
+		{8, 0, []string{"add", "x", "name", "puts"}, []string{"sum", "a"}},
+	}
+
+	c := newClient(t)
+	c.start(source)
+
+	for _, tt := range tests {
+		var items []CompletionItem
+		if _, err := c.call("textDocument/completion", position(tt.line, tt.character), &items); err != nil {
+			t.Fatalf("completion failed: %s", err.Message)
+		}
+
+		labels := map[string]int{}
+		for _, item := range items {
+			labels[item.Label] = item.Kind
+		}
+		for _, label := range tt.present {
+			if _, ok := labels[label]; !ok {
+				t.Errorf("%d:%d: expected completion %q", tt.line, tt.character, label)
+			}
+		}
+		for _, label := range tt.absent {
+			if _, ok := labels[label]; ok {
+				t.Errorf("%d:%d: unexpected completion %q", tt.line, tt.character, label)
+			}
+		}
+		if labels["add"] != CompletionFunction || labels["let"] != CompletionKeyword {
+			t.Errorf("%d:%d: wrong completion kinds %v", tt.line, tt.character, labels)
+		}
+	}
+
+	c.stop()
+}
+
+func TestFormatting(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []TextEdit // nil for a null result
+	}{
+		{"let x=1\nx", []TextEdit{{Range: Range{End: Position{1, 1}}, NewText: "let x = 1;\nx;\n"}}},
+		{"let x = 1;\n", []TextEdit{}},
+		{"let x = ;", nil},
+	}
+
+	for _, tt := range tests {
+		c := newClient(t)
+		c.start(tt.input)
+
+		var edits []TextEdit
+		result, err := c.call("textDocument/formatting", DocumentFormattingParams{TextDocument: TextDocumentIdentifier{URI: uri}}, &edits)
+		if err != nil {
+			t.Fatalf("formatting failed: %s", err.Message)
+		}
+
+		if tt.expected == nil {
+			if string(result) != "null" {
+				t.Errorf("%q: expected null, got %s", tt.input, result)
+			}
+		} else if !reflect.DeepEqual(edits, tt.expected) {
+			t.Errorf("%q: wrong edits, got %+v, want %+v", tt.input, edits, tt.expected)
+		}
+
+		c.stop()
+	}
+}
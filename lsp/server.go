@@ -0,0 +1,194 @@
+// lsp/server.go
+
+// Package lsp implements a language server for Monkey that speaks the
+// Language Server Protocol over a stream.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// server holds the state of a session with a client. Messages are handled
+// one at a time in the order they arrive.
+type server struct {
+	out         io.Writer
+	docs        map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+type handler func(s *server, params json.RawMessage) (interface{}, error)
+
+// requests are the methods the server answers.
+var requests = map[string]handler{
+	"initialize":                  (*server).initialize,
+	"shutdown":                    (*server).shutdownRequest,
+	"textDocument/hover":          (*server).hover,
+	"textDocument/definition":     (*server).definition,
+	"textDocument/documentSymbol": (*server).documentSymbol,
+	"textDocument/completion":     (*server).completion,
+	"textDocument/formatting":     (*server).formatting,
+}
+
+// notifications are the notifications the server acts on; others are
+// ignored.
+var notifications = map[string]handler{
+	"initialized":            func(*server, json.RawMessage) (interface{}, error) { return nil, nil },
+	"textDocument/didOpen":   (*server).didOpen,
+	"textDocument/didChange": (*server).didChange,
+	"textDocument/didClose":  (*server).didClose,
+}
+
+// Serve runs a language server reading messages from in and writing to out
+// until the client sends exit or closes in. It returns an error if the
+// session did not end with a shutdown request.
+func Serve(in io.Reader, out io.Writer) error {
+	s := &server{out: out, docs: make(map[string]*document)}
+	r := bufio.NewReader(in)
+
+	for {
+		content, err := readMessage(r)
+		if err == io.EOF {
+			if !s.shutdown {
+				return errors.New("input closed without shutdown")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(content, &msg); err != nil {
+			err := s.reply(nil, nil, &rpcError{codeParseError, err.Error()})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+
+		if err := s.handle(&msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle handles a request or notification and returns an error if the
+// response could not be written.
+func (s *server) handle(msg *message) error {
+	if msg.ID == nil {
+		h, ok := notifications[msg.Method]
+		if !ok || !s.initialized || s.shutdown {
+			return nil
+		}
+		_, err := h(s, msg.Params)
+		return err
+	}
+
+	h, ok := requests[msg.Method]
+	switch {
+	case msg.Method == "":
+		return s.reply(msg.ID, nil, &rpcError{codeInvalidRequest, "missing method"})
+	case !s.initialized && msg.Method != "initialize":
+		return s.reply(msg.ID, nil, &rpcError{codeServerNotInitialized, "server not initialized"})
+	case s.shutdown:
+		return s.reply(msg.ID, nil, &rpcError{codeInvalidRequest, "server is shut down"})
+	case !ok:
+		return s.reply(msg.ID, nil, &rpcError{codeMethodNotFound, "method not found: " + msg.Method})
+	}
+
+	result, err := h(s, msg.Params)
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{codeInvalidParams, err.Error()}
+		}
+		return s.reply(msg.ID, nil, rpcErr)
+	}
+	return s.reply(msg.ID, result, nil)
+}
+
+func (s *server) reply(id *json.RawMessage, result interface{}, err *rpcError) error {
+	if err != nil {
+		return writeMessage(s.out, errorResponse{JSONRPC: "2.0", ID: id, Error: err})
+	}
+	return writeMessage(s.out, response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *server) initialize(params json.RawMessage) (interface{}, error) {
+	if s.initialized {
+		return nil, &rpcError{codeInvalidRequest, "server already initialized"}
+	}
+	s.initialized = true
+
+	return InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           SyncFull,
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			DocumentSymbolProvider:     true,
+			CompletionProvider:         struct{}{},
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: ServerInfo{Name: "monkey"},
+	}, nil
+}
+
+func (s *server) shutdownRequest(params json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *server) didOpen(params json.RawMessage) (interface{}, error) {
+	var p DidOpenTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, nil
+	}
+	return nil, s.update(p.TextDocument.URI, p.TextDocument.Text)
+}
+
+func (s *server) didChange(params json.RawMessage) (interface{}, error) {
+	var p DidChangeTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil || len(p.ContentChanges) == 0 {
+		return nil, nil
+	}
+	// With full sync the last change holds the whole document.
+	return nil, s.update(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+}
+
+func (s *server) didClose(params json.RawMessage) (interface{}, error) {
+	var p DidCloseTextDocumentParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, nil
+	}
+	delete(s.docs, p.TextDocument.URI)
+	return nil, s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// update replaces the text of a document and publishes its diagnostics.
+func (s *server) update(uri, text string) error {
+	d := newDocument(uri, strings.ReplaceAll(text, "\r\n", "\n"))
+	s.docs[uri] = d
+	return s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: d.diagnostics(),
+	})
+}
@@ -0,0 +1,94 @@
+// object/concurrency.go
+
+package object
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Task is the result of a function call running on its own goroutine.
+type Task struct {
+	done   chan struct{}
+	result Object
+}
+
+func NewTask() *Task {
+	return &Task{done: make(chan struct{})}
+}
+
+func (t *Task) Inspect() string  { return "task" }
+func (t *Task) Type() ObjectType { return TASK_OBJ }
+
+// Complete records the result of the task and wakes up its waiters. It must
+// be called exactly once.
+func (t *Task) Complete(result Object) {
+	t.result = result
+	close(t.done)
+}
+
+// Wait blocks until the task has completed and returns its result. An error
+// is copied for every waiter, as the evaluator adds the position and trace of
+// the waiter to it.
+func (t *Task) Wait() Object {
+	<-t.done
+	if err, ok := t.result.(*Error); ok {
+		copied := *err
+		copied.Trace = err.Trace[:len(err.Trace):len(err.Trace)]
+		return &copied
+	}
+	return t.result
+}
+
+// Done returns a channel that is closed once the task has completed.
+func (t *Task) Done() <-chan struct{} {
+	return t.done
+}
+
+// Channel passes values between tasks. A size of zero makes an unbuffered
+// channel.
+type Channel struct {
+	C    chan Object
+	Size int
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func NewChannel(size int) *Channel {
+	return &Channel{C: make(chan Object, size), Size: size}
+}
+
+func (c *Channel) Inspect() string  { return fmt.Sprintf("channel(%d)", c.Size) }
+func (c *Channel) Type() ObjectType { return CHANNEL_OBJ }
+
+// Send blocks until val has been delivered or buffered. It returns false if
+// the channel is closed.
+func (c *Channel) Send(val Object) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	c.C <- val
+	return true
+}
+
+// Recv blocks until a value is available. It returns false once the channel
+// is closed and drained.
+func (c *Channel) Recv() (Object, bool) {
+	val, ok := <-c.C
+	return val, ok
+}
+
+// Close closes the channel. It returns false if it was already closed.
+func (c *Channel) Close() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	c.closed = true
+	close(c.C)
+	return true
+}
@@ -1,30 +1,138 @@
 package object
 
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/solbero/monkey/ast"
+)
+
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
 	return env
 }
 
+// YieldFunc suspends the running generator with a value. It returns the
+// result of the yield expression.
+type YieldFunc func(Object) Object
+
+// Tracer follows the evaluation of a program, e.g. to debug it.
+type Tracer interface {
+	// Statement is called before a statement is evaluated. A non-nil result
+	// ends evaluation with it instead.
+	Statement(node ast.Statement, env *Environment) Object
+	// Call and Return are called around the evaluation of the body of a
+	// function in env.
+	Call(fn *Function, env *Environment)
+	Return(fn *Function)
+}
+
+// Environment is safe for concurrent use, so spawned tasks may share the
+// environment they were created in.
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	mu     sync.RWMutex
+	store  map[string]Object
+	consts map[string]bool
+	outer  *Environment
+	yield  YieldFunc
+	tracer Tracer
 }
 
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s}
+	c := make(map[string]bool)
+	return &Environment{store: s, consts: c}
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
+	e.mu.RLock()
 	obj, ok := e.store[name]
+	e.mu.RUnlock()
 	if !ok && e.outer != nil {
 		obj, ok = e.outer.Get(name)
 	}
 	return obj, ok
 }
 
+// Set binds name in this environment. Rebinding a constant of the same
+// environment is refused with an *Error; constants of outer environments may
+// be shadowed.
 func (e *Environment) Set(name string, val Object) Object {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.consts[name] {
+		return rebindError(name)
+	}
 	e.store[name] = val
 	return val
 }
+
+// SetConst binds name like Set and marks the binding as constant.
+func (e *Environment) SetConst(name string, val Object) Object {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.consts[name] {
+		return rebindError(name)
+	}
+	e.store[name] = val
+	e.consts[name] = true
+	return val
+}
+
+// Names returns the names bound in this environment, without those of outer
+// environments, in sorted order.
+func (e *Environment) Names() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsConst reports whether name is a constant of this environment.
+func (e *Environment) IsConst(name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.consts[name]
+}
+
+// Outer returns the enclosing environment, or nil for the global one.
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+func rebindError(name string) *Error {
+	return &Error{Message: fmt.Sprintf("cannot rebind constant: %s", name)}
+}
+
+// SetYield marks the environment as the body of a generator.
+func (e *Environment) SetYield(yield YieldFunc) {
+	e.yield = yield
+}
+
+// Yield returns the yield function of the nearest enclosing generator.
+func (e *Environment) Yield() (YieldFunc, bool) {
+	if e.yield == nil && e.outer != nil {
+		return e.outer.Yield()
+	}
+	return e.yield, e.yield != nil
+}
+
+// SetTracer makes tracer follow the evaluation of code in the environment and
+// the environments enclosed by it. It must be set before evaluation starts.
+func (e *Environment) SetTracer(tracer Tracer) {
+	e.tracer = tracer
+}
+
+// Tracer returns the tracer of the nearest enclosing environment that has one.
+func (e *Environment) Tracer() (Tracer, bool) {
+	if e.tracer == nil && e.outer != nil {
+		return e.outer.Tracer()
+	}
+	return e.tracer, e.tracer != nil
+}
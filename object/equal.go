@@ -0,0 +1,96 @@
+// object/equal.go
+
+package object
+
+// Equal reports whether a and b are structurally equal. Arrays, hashes and
+// structs are compared element by element, functions are equal when they were
+// created from the same literal in the same environment, and all other
+// values are compared by their content or identity.
+func Equal(a, b Object) bool {
+	return equal(a, b, make(map[[2]Object]bool))
+}
+
+func equal(a, b Object, visiting map[[2]Object]bool) bool {
+	if a == b {
+		return true
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		return a.Value == b.(*Integer).Value
+	case *String:
+		return a.Value == b.(*String).Value
+	case *Boolean:
+		return a.Value == b.(*Boolean).Value
+	case *Null:
+		return true
+	case *Function:
+		b := b.(*Function)
+		return a.Body == b.Body && a.Env == b.Env
+	case *Array:
+		b := b.(*Array)
+		if len(a.Elements) != len(b.Elements) {
+			return false
+		}
+
+		// A pair that is already being compared further up the stack is
+		// assumed equal, which stops the recursion on cyclic values.
+		pair := [2]Object{a, b}
+		if visiting[pair] {
+			return true
+		}
+		visiting[pair] = true
+		defer delete(visiting, pair)
+
+		for i := range a.Elements {
+			if !equal(a.Elements[i], b.Elements[i], visiting) {
+				return false
+			}
+		}
+		return true
+	case *Struct:
+		b := b.(*Struct)
+		if a.Definition != b.Definition {
+			return false
+		}
+
+		pair := [2]Object{a, b}
+		if visiting[pair] {
+			return true
+		}
+		visiting[pair] = true
+		defer delete(visiting, pair)
+
+		for i := range a.Values {
+			if !equal(a.Values[i], b.Values[i], visiting) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b := b.(*Hash)
+		if len(a.Pairs) != len(b.Pairs) {
+			return false
+		}
+
+		pair := [2]Object{a, b}
+		if visiting[pair] {
+			return true
+		}
+		visiting[pair] = true
+		defer delete(visiting, pair)
+
+		for key, pa := range a.Pairs {
+			pb, ok := b.Pairs[key]
+			if !ok || !equal(pa.Key, pb.Key, visiting) || !equal(pa.Value, pb.Value, visiting) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,23 @@
+// object/inspect.go
+
+package object
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxInspect is the number of characters Summarize cuts values to.
+const MaxInspect = 60
+
+// Summarize cuts s, usually the Inspect of a value, to its first line and at
+// most MaxInspect characters, marking anything left out with " ...".
+func Summarize(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i] + " ..."
+	}
+	if utf8.RuneCountInString(s) > MaxInspect {
+		s = string([]rune(s)[:MaxInspect]) + " ..."
+	}
+	return s
+}
@@ -4,10 +4,13 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"hash/fnv"
 	"github.com/solbero/monkey/ast"
+	"sort"
 	"strings"
+	"sync/atomic"
 )
 
 type ObjectType string
@@ -23,6 +26,12 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	STRUCT_TYPE_OBJ  = "STRUCT_TYPE"
+	STRUCT_OBJ       = "STRUCT"
+	ITERATOR_OBJ     = "ITERATOR"
+	TASK_OBJ         = "TASK"
+	CHANNEL_OBJ      = "CHANNEL"
+	EXIT_OBJ         = "EXIT"
 )
 
 type Object interface {
@@ -36,13 +45,34 @@ type Hashable interface {
 
 type BuiltinFunction func(args ...Object) Object
 
+// Error is a runtime error. Line and Column locate the expression that
+// failed, 0 if unknown, and Trace holds the function calls it unwound,
+// innermost first.
 type Error struct {
 	Message string
+	Line    int
+	Column  int
+	Trace   []Frame
 }
 
 func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
 
+// Frame is a call of a function. Function is empty for anonymous functions.
+type Frame struct {
+	Function string
+	Line     int
+	Column   int
+}
+
+// Exit unwinds evaluation like an error and ends the program with Code.
+type Exit struct {
+	Code int
+}
+
+func (e *Exit) Inspect() string  { return fmt.Sprintf("exit(%d)", e.Code) }
+func (e *Exit) Type() ObjectType { return EXIT_OBJ }
+
 type Integer struct {
 	Value int64
 }
@@ -72,9 +102,11 @@ func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 
 type Function struct {
-	Parameters []*ast.Identifier
-	Body       *ast.BlockStatement
-	Env        *Environment
+	Parameters  []*ast.Identifier
+	Body        *ast.BlockStatement
+	Env         *Environment
+	IsGenerator bool
+	Name        string // the name the function literal was bound to by let
 }
 
 func (f *Function) Inspect() string {
@@ -105,6 +137,7 @@ func (b *Builtin) Type() ObjectType { return BUILTIN_OBJ }
 
 type Array struct {
 	Elements []Object
+	Frozen   atomic.Bool // see Freeze
 }
 
 func (ao *Array) Inspect() string {
@@ -129,14 +162,70 @@ type HashPair struct {
 }
 
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	Pairs  map[HashKey]HashPair
+	Frozen atomic.Bool // see Freeze
+	keys   []HashKey   // insertion order of Pairs
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+// Set adds or replaces a pair. New keys are appended to the iteration order,
+// replaced keys keep their position.
+func (h *Hash) Set(key HashKey, pair HashPair) {
+	if _, ok := h.Pairs[key]; !ok {
+		h.keys = append(h.keys, key)
+	}
+	h.Pairs[key] = pair
+}
+
+func (h *Hash) Delete(key HashKey) {
+	if _, ok := h.Pairs[key]; !ok {
+		return
+	}
+
+	delete(h.Pairs, key)
+	for i, k := range h.keys {
+		if k == key {
+			h.keys = append(h.keys[:i:i], h.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// OrderedPairs returns the pairs of the hash in insertion order. Pairs put
+// in Pairs directly rather than by Set come last, ordered by key.
+func (h *Hash) OrderedPairs() []HashPair {
+	pairs := make([]HashPair, 0, len(h.Pairs))
+	ordered := make(map[HashKey]bool, len(h.keys))
+	for _, key := range h.keys {
+		if pair, ok := h.Pairs[key]; ok {
+			pairs = append(pairs, pair)
+			ordered[key] = true
+		}
+	}
+	if len(pairs) == len(h.Pairs) {
+		return pairs
+	}
+
+	rest := make([]HashPair, 0, len(h.Pairs)-len(pairs))
+	for key, pair := range h.Pairs {
+		if !ordered[key] {
+			rest = append(rest, pair)
+		}
+	}
+	sort.Slice(rest, func(i, j int) bool {
+		return rest[i].Key.Inspect() < rest[j].Key.Inspect()
+	})
+	return append(pairs, rest...)
 }
 
 func (h *Hash) Inspect() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range h.Pairs {
+	for _, pair := range h.OrderedPairs() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 
@@ -176,6 +265,142 @@ func (s *String) HashKey() HashKey {
 	return HashKey{Type: s.Type(), Value: h.Sum64()}
 }
 
+// HashKey combines the hash keys of the elements. Arrays holding elements
+// that are not hashable are not usable as keys, see HashKeyOf.
+func (ao *Array) HashKey() HashKey {
+	h := fnv.New64a()
+	buf := make([]byte, 8)
+
+	for _, el := range ao.Elements {
+		h.Write([]byte(el.Type()))
+		if key, ok := HashKeyOf(el); ok {
+			binary.LittleEndian.PutUint64(buf, key.Value)
+			h.Write(buf)
+		}
+	}
+
+	return HashKey{Type: ao.Type(), Value: h.Sum64()}
+}
+
+// HashKeyOf returns the hash key of obj and whether obj is usable as a key.
+func HashKeyOf(obj Object) (HashKey, bool) {
+	if array, ok := obj.(*Array); ok {
+		for _, el := range array.Elements {
+			if _, ok := HashKeyOf(el); !ok {
+				return HashKey{}, false
+			}
+		}
+	}
+
+	hashable, ok := obj.(Hashable)
+	if !ok {
+		return HashKey{}, false
+	}
+
+	return hashable.HashKey(), true
+}
+
+// Iterator produces values lazily. Next returns false once the iterator is
+// exhausted; an *Error value reports a failure while producing the value.
+type Iterator struct {
+	Next func() (Object, bool)
+}
+
+func (it *Iterator) Inspect() string  { return "iterator" }
+func (it *Iterator) Type() ObjectType { return ITERATOR_OBJ }
+
+// StructType is the constructor created by a struct declaration.
+type StructType struct {
+	Name   string
+	Fields []string
+}
+
+func (st *StructType) Inspect() string {
+	return fmt.Sprintf("struct %s { %s }", st.Name, strings.Join(st.Fields, ", "))
+}
+func (st *StructType) Type() ObjectType { return STRUCT_TYPE_OBJ }
+
+// FieldIndex returns the position of field in Fields, or -1.
+func (st *StructType) FieldIndex(field string) int {
+	for i, f := range st.Fields {
+		if f == field {
+			return i
+		}
+	}
+	return -1
+}
+
+// Struct is an instance of a StructType. Values are in the order of the
+// type's Fields.
+type Struct struct {
+	Definition *StructType
+	Values     []Object
+	Frozen     atomic.Bool // see Freeze
+}
+
+func (s *Struct) Inspect() string {
+	var out bytes.Buffer
+
+	fields := []string{}
+	for i, f := range s.Definition.Fields {
+		fields = append(fields, fmt.Sprintf("%s: %s", f, s.Values[i].Inspect()))
+	}
+
+	out.WriteString(s.Definition.Name)
+	out.WriteString("{")
+	out.WriteString(strings.Join(fields, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+func (s *Struct) Type() ObjectType { return STRUCT_OBJ }
+
+// Freeze marks obj and every array, hash and struct reachable from it as
+// frozen. Operations that modify values in place must refuse frozen values.
+// The flag is atomic, as values are shared between tasks.
+func Freeze(obj Object) {
+	switch obj := obj.(type) {
+	case *Array:
+		if !obj.Frozen.CompareAndSwap(false, true) {
+			return
+		}
+		for _, el := range obj.Elements {
+			Freeze(el)
+		}
+	case *Hash:
+		if !obj.Frozen.CompareAndSwap(false, true) {
+			return
+		}
+		for _, pair := range obj.Pairs {
+			Freeze(pair.Key)
+			Freeze(pair.Value)
+		}
+	case *Struct:
+		if !obj.Frozen.CompareAndSwap(false, true) {
+			return
+		}
+		for _, val := range obj.Values {
+			Freeze(val)
+		}
+	}
+}
+
+// IsFrozen reports whether obj can not be modified. Values other than arrays,
+// hashes and structs are always immutable. A struct is frozen only by Freeze,
+// as its fields may hold mutable values.
+func IsFrozen(obj Object) bool {
+	switch obj := obj.(type) {
+	case *Array:
+		return obj.Frozen.Load()
+	case *Hash:
+		return obj.Frozen.Load()
+	case *Struct:
+		return obj.Frozen.Load()
+	default:
+		return true
+	}
+}
+
 type Null struct{}
 
 func (n *Null) Inspect() string  { return "null" }
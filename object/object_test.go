@@ -3,6 +3,7 @@
 package object
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -57,3 +58,134 @@ func TestIntegerHashKey(t *testing.T) {
 		t.Errorf("integers with different content have same hash keys")
 	}
 }
+
+func TestHashOrder(t *testing.T) {
+	hash := NewHash()
+	for _, key := range []string{"c", "a", "b"} {
+		str := &String{Value: key}
+		hash.Set(str.HashKey(), HashPair{Key: str, Value: str})
+	}
+
+	a := &String{Value: "a"}
+	hash.Set(a.HashKey(), HashPair{Key: a, Value: &Integer{Value: 1}})
+	hash.Delete((&String{Value: "c"}).HashKey())
+
+	if hash.Inspect() != "{a: 1, b: b}" {
+		t.Errorf("hash has wrong order, got %s", hash.Inspect())
+	}
+}
+
+func TestHashOrderWithoutSet(t *testing.T) {
+	a, b, c := &String{Value: "a"}, &String{Value: "b"}, &String{Value: "c"}
+	hash := &Hash{Pairs: map[HashKey]HashPair{
+		c.HashKey(): {Key: c, Value: c},
+		a.HashKey(): {Key: a, Value: a},
+	}}
+	if hash.Inspect() != "{a: a, c: c}" {
+		t.Errorf("hash literal has wrong pairs, got %s", hash.Inspect())
+	}
+
+	hash = NewHash()
+	hash.Set(c.HashKey(), HashPair{Key: c, Value: c})
+	hash.Pairs[b.HashKey()] = HashPair{Key: b, Value: b}
+	hash.Pairs[a.HashKey()] = HashPair{Key: a, Value: a}
+	if hash.Inspect() != "{c: c, a: a, b: b}" {
+		t.Errorf("hash has wrong order, got %s", hash.Inspect())
+	}
+}
+
+func TestArrayHashKey(t *testing.T) {
+	arr1 := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	arr2 := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	diff := &Array{Elements: []Object{&String{Value: "a"}, &Integer{Value: 1}}}
+
+	if arr1.HashKey() != arr2.HashKey() {
+		t.Errorf("arrays with same content have different hash keys")
+	}
+
+	if arr1.HashKey() == diff.HashKey() {
+		t.Errorf("arrays with different content have same hash keys")
+	}
+
+	unhashable := &Array{Elements: []Object{&Builtin{}}}
+	if _, ok := HashKeyOf(unhashable); ok {
+		t.Errorf("array with unhashable element is usable as hash key")
+	}
+}
+
+func TestEqualCyclic(t *testing.T) {
+	arr1 := &Array{Elements: []Object{&Integer{Value: 1}, nil}}
+	arr1.Elements[1] = arr1
+	arr2 := &Array{Elements: []Object{&Integer{Value: 1}, nil}}
+	arr2.Elements[1] = arr2
+
+	if !Equal(arr1, arr2) {
+		t.Errorf("cyclic arrays with same shape are not equal")
+	}
+
+	arr3 := &Array{Elements: []Object{&Integer{Value: 2}, nil}}
+	arr3.Elements[1] = arr3
+
+	if Equal(arr1, arr3) {
+		t.Errorf("cyclic arrays with different content are equal")
+	}
+}
+
+func TestEnvironmentConst(t *testing.T) {
+	env := NewEnvironment()
+	env.SetConst("config", &Integer{Value: 1})
+
+	if result := env.Set("config", &Integer{Value: 2}); result.Type() != ERROR_OBJ {
+		t.Errorf("constant was rebound, got %s", result.Inspect())
+	}
+
+	inner := NewEnclosedEnvironment(env)
+	if result := inner.Set("config", &Integer{Value: 3}); result.Type() == ERROR_OBJ {
+		t.Errorf("constant of outer environment could not be shadowed: %s", result.Inspect())
+	}
+
+	if obj, _ := env.Get("config"); obj.Inspect() != "1" {
+		t.Errorf("constant has wrong value, got %s", obj.Inspect())
+	}
+}
+
+func TestEnvironmentNames(t *testing.T) {
+	env := NewEnvironment()
+	env.Set("b", &Integer{Value: 1})
+	env.SetConst("a", &Integer{Value: 2})
+	inner := NewEnclosedEnvironment(env)
+	inner.Set("c", &Integer{Value: 3})
+
+	if names := env.Names(); len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("wrong names, got %v", names)
+	}
+	if names := inner.Names(); len(names) != 1 || names[0] != "c" {
+		t.Errorf("wrong names of inner environment, got %v", names)
+	}
+	if !env.IsConst("a") || env.IsConst("b") || inner.IsConst("a") {
+		t.Errorf("wrong constants")
+	}
+	if inner.Outer() != env || env.Outer() != nil {
+		t.Errorf("wrong outer environments")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	long := strings.Repeat("é", MaxInspect)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"42", "42"},
+		{"a\nb", "a ..."},
+		{long, long},
+		{long + "é", long + " ..."},
+		{"x" + long, "x" + long[:len(long)-len("é")] + " ..."},
+	}
+
+	for _, tt := range tests {
+		if got := Summarize(tt.input); got != tt.expected {
+			t.Errorf("Summarize(%q) wrong, expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 const (
 	_ int = iota
 	LOWEST
+	PIPE        // x |> f
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
@@ -20,9 +21,11 @@ const (
 	PREFIX      // -X or !X
 	CALL        // myFunction(X
 	INDEX       // array[index]
+	MEMBER      // hash.key
 )
 
 var precedences = map[token.TokenType]int{
+	token.PIPE:     PIPE,
 	token.EQ:       EQUALS,
 	token.NOT_EQ:   EQUALS,
 	token.LT:       LESSGREATER,
@@ -33,6 +36,16 @@ var precedences = map[token.TokenType]int{
 	token.ASTERISK: PRODUCT,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
+	token.DOT:      MEMBER,
+}
+
+// Precedence returns the binding power of an infix operator, or LOWEST for
+// any other token.
+func Precedence(t token.TokenType) int {
+	if p, ok := precedences[t]; ok {
+		return p
+	}
+	return LOWEST
 }
 
 type (
@@ -48,6 +61,7 @@ func New(l *lexer.Lexer) *Parser {
 
 	// Register prefix parse functions for the parser
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
@@ -58,8 +72,12 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.RAW_STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TEMPLATE, p.parseInterpolatedString)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.YIELD, p.parseYieldExpression)
+	p.registerPrefix(token.SPAWN, p.parseSpawnExpression)
 
 	// Register infix parse functions for the parser
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
@@ -73,6 +91,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+	p.registerInfix(token.PIPE, p.parsePipeExpression)
 
 	// Read two tokens, so curToken and peekToken are both set
 	p.nextToken()
@@ -82,14 +102,18 @@ func New(l *lexer.Lexer) *Parser {
 }
 
 type Parser struct {
-	l      *lexer.Lexer
-	errors []string
+	l         *lexer.Lexer
+	errors    []string
+	errorList []lexer.Error // errors with their positions
 
 	curToken  token.Token
 	peekToken token.Token
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	functions   []*ast.FunctionLiteral // function literals enclosing curToken
+	lexerErrors int                    // number of lexer errors taken over
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
@@ -109,6 +133,11 @@ func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+// ErrorList returns the errors of the lexer and parser with their positions.
+func (p *Parser) ErrorList() []lexer.Error {
+	return p.errorList
+}
+
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
 	p.prefixParseFns[tokenType] = fn
 }
@@ -120,14 +149,32 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	// Take over the errors the lexer found while reading the token.
+	if errors := p.l.ErrorList(); len(errors) > p.lexerErrors {
+		for _, err := range errors[p.lexerErrors:] {
+			p.errors = append(p.errors, err.String())
+			p.errorList = append(p.errorList, err)
+		}
+		p.lexerErrors = len(errors)
+	}
+}
+
+// error records an error found at tok.
+func (p *Parser) error(tok token.Token, format string, a ...interface{}) {
+	err := lexer.Error{Line: tok.Line, Column: tok.Column, Message: fmt.Sprintf(format, a...)}
+	p.errors = append(p.errors, err.String())
+	p.errorList = append(p.errorList, err)
 }
 
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
-	case token.LET:
+	case token.LET, token.CONST:
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.STRUCT:
+		return p.parseStructStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -171,6 +218,48 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
+func (p *Parser) parseStructStatement() *ast.StructStatement {
+	stmt := &ast.StructStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for !p.peekTokenIs(token.RBRACE) {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		field := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if seen[field.Value] {
+			p.error(field.Token, "Duplicate field %s in struct %s", field.Value, stmt.Name.Value)
+		}
+		seen[field.Value] = true
+		stmt.Fields = append(stmt.Fields, field)
+
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 
@@ -198,8 +287,21 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	return block
 }
 
+// parseIllegal skips an illegal character, which the lexer has already
+// reported.
+func (p *Parser) parseIllegal() ast.Expression {
+	return nil
+}
+
 func (p *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.ARROW) {
+		p.nextToken()
+		return p.parseArrowFunction([]*ast.Identifier{ident})
+	}
+
+	return ident
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
@@ -226,8 +328,7 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 }
 
 func (p *Parser) noPrefixParseError(t token.TokenType) {
-	msg := fmt.Sprintf("No prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.error(p.curToken, "No prefix parse function for %s found", t)
 }
 
 func (p *Parser) peekPrecedence() int {
@@ -270,15 +371,69 @@ func (p *Parser) curPrecedence() int {
 	return LOWEST
 }
 
+// parseGroupedExpression parses (x) as well as the parameter lists of the
+// arrow functions () => x and (a, b) => x.
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		if !p.expectPeek(token.ARROW) {
+			return nil
+		}
+		return p.parseArrowFunction([]*ast.Identifier{})
+	}
+
 	p.nextToken()
-	exp := p.parseExpression(LOWEST)
+	exps := []ast.Expression{p.parseExpression(LOWEST)}
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		p.nextToken()
+		exps = append(exps, p.parseExpression(LOWEST))
+	}
 
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
 
-	return exp
+	if len(exps) == 1 && !p.peekTokenIs(token.ARROW) {
+		return exps[0]
+	}
+
+	if !p.expectPeek(token.ARROW) {
+		return nil
+	}
+
+	params := make([]*ast.Identifier, len(exps))
+	for i, exp := range exps {
+		ident, ok := exp.(*ast.Identifier)
+		if !ok {
+			line, column := exp.Position()
+			p.error(token.Token{Line: line, Column: column}, "Expected identifier as arrow function parameter, got %s", exp)
+			return nil
+		}
+		params[i] = ident
+	}
+
+	return p.parseArrowFunction(params)
+}
+
+// parseArrowFunction parses the body following '=>'. A block body is used
+// as is, any other expression becomes the single statement of the body.
+func (p *Parser) parseArrowFunction(params []*ast.Identifier) ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken, Parameters: params}
+
+	p.functions = append(p.functions, lit)
+	p.nextToken()
+	if p.curTokenIs(token.LBRACE) {
+		lit.Body = p.parseBlockStatement()
+	} else {
+		stmt := &ast.ExpressionStatement{Token: p.curToken}
+		stmt.Expression = p.parseExpression(LOWEST)
+		lit.Body = &ast.BlockStatement{Token: lit.Token, Statements: []ast.Statement{stmt}}
+	}
+	p.functions = p.functions[:len(p.functions)-1]
+
+	return lit
 }
 
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
@@ -336,6 +491,16 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	exp := &ast.PipeExpression{Token: p.curToken, Left: left}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	exp.Right = p.parseExpression(precedence)
+
+	return exp
+}
+
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
 	exp.Arguments = p.parseExpressionList(token.RPAREN)
@@ -356,7 +521,9 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
+	p.functions = append(p.functions, lit)
 	lit.Body = p.parseBlockStatement()
+	p.functions = p.functions[:len(p.functions)-1]
 
 	return lit
 }
@@ -389,10 +556,33 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 }
 
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	tok := p.curToken
 
-	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
+	var index ast.Expression
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		index = p.parseExpression(LOWEST)
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		return p.parseSliceExpression(tok, left, index)
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return &ast.IndexExpression{Token: tok, Left: left, Index: index}
+}
+
+func (p *Parser) parseSliceExpression(tok token.Token, left, start ast.Expression) ast.Expression {
+	exp := &ast.SliceExpression{Token: tok, Left: left, Start: start}
+
+	if !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		exp.End = p.parseExpression(LOWEST)
+	}
 
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
@@ -401,14 +591,53 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Object: left}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
+func (p *Parser) parseYieldExpression() ast.Expression {
+	exp := &ast.YieldExpression{Token: p.curToken}
+
+	if len(p.functions) == 0 {
+		p.error(p.curToken, "Yield outside of function")
+	} else {
+		p.functions[len(p.functions)-1].IsGenerator = true
+	}
+
+	p.nextToken()
+	exp.Value = p.parseExpression(LOWEST)
+
+	return exp
+}
+
+func (p *Parser) parseSpawnExpression() ast.Expression {
+	exp := &ast.SpawnExpression{Token: p.curToken}
+
+	p.nextToken()
+	call, ok := p.parseExpression(PREFIX).(*ast.CallExpression)
+	if !ok {
+		p.error(exp.Token, "Expected call expression after spawn")
+		return nil
+	}
+	exp.Call = call
+
+	return exp
+}
+
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("Could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
-		return nil
+		return nil // malformed numbers are reported by the lexer
 	}
 
 	lit.Value = value
@@ -420,6 +649,33 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+// parseInterpolatedString parses a string with embedded ${...} expressions.
+// It starts on the TEMPLATE token holding the text before the first
+// expression and ends on the TEMPLATE_END token holding the text after the
+// last one.
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+
+	for {
+		if p.curToken.Literal != "" {
+			str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+		}
+		if p.curTokenIs(token.TEMPLATE_END) {
+			return str
+		}
+
+		p.nextToken()
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+
+		if !p.peekTokenIs(token.TEMPLATE) && !p.expectPeek(token.TEMPLATE_END) {
+			return nil
+		}
+		if p.peekTokenIs(token.TEMPLATE) {
+			p.nextToken()
+		}
+	}
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 
@@ -443,6 +699,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 
 		value := p.parseExpression(LOWEST)
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
@@ -480,6 +737,5 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 }
 
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("Expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.error(p.peekToken, "Expected next token to be %s, got %s instead", t, p.peekToken.Type)
 }
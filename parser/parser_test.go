@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/solbero/monkey/ast"
 	"github.com/solbero/monkey/lexer"
+	"reflect"
 	"testing"
 )
 
@@ -42,6 +43,287 @@ func TestLetStatements(t *testing.T) {
 	}
 }
 
+func TestConstStatements(t *testing.T) {
+	input := "const x = 5;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program has not enough statements, got %d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.LetStatement, got %T", program.Statements[0])
+	}
+	if !stmt.IsConst() {
+		t.Errorf("stmt.IsConst() is false")
+	}
+	if stmt.String() != input {
+		t.Errorf("stmt.String() wrong, expected %q, got %q", input, stmt.String())
+	}
+	checkLiteralExpression(t, stmt.Value, 5)
+}
+
+func TestStructStatements(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedName   string
+		expectedFields []string
+	}{
+		{"struct Point { x, y }", "Point", []string{"x", "y"}},
+		{"struct Point { x, y, };", "Point", []string{"x", "y"}},
+		{"struct Empty {}", "Empty", []string{}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program has not enough statements, got %d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.StructStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.StructStatement, got %T", program.Statements[0])
+		}
+		if !checkIdentifier(t, stmt.Name, tt.expectedName) {
+			continue
+		}
+		if len(stmt.Fields) != len(tt.expectedFields) {
+			t.Errorf("wrong number of fields, want %d, got %d", len(tt.expectedFields), len(stmt.Fields))
+			continue
+		}
+		for i, field := range tt.expectedFields {
+			checkIdentifier(t, stmt.Fields[i], field)
+		}
+	}
+}
+
+func TestStructStatementErrors(t *testing.T) {
+	tests := []string{
+		"struct { x }",
+		"struct Point { x y }",
+		"struct Point { x, x }",
+		"struct Point { 1 }",
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parser errors for %q", input)
+		}
+	}
+}
+
+func TestYieldExpressions(t *testing.T) {
+	tests := []struct {
+		input      string
+		generators []bool
+	}{
+		{"fn() { yield 1 }", []bool{true}},
+		{"fn() { 1 }", []bool{false}},
+		{"fn() { fn() { yield 1 } }", []bool{false, true}},
+		{"fn() { let f = fn(x) { yield x }; f(1) }", []bool{false, true}},
+		{"fn() { each(xs, fn(x) { yield x }) }", []bool{false, true}},
+		{"fn() { xs.each((x) => yield x) }", []bool{false, true}},
+		{"fn() { let xs = collect(fn() { yield 1 }); 5 }", []bool{false, true}},
+		{"each(xs, fn(x) { yield x })", []bool{true}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		literals := []*ast.FunctionLiteral{}
+		ast.Inspect(program, func(node ast.Node) bool {
+			if fn, ok := node.(*ast.FunctionLiteral); ok {
+				literals = append(literals, fn)
+			}
+			return true
+		})
+
+		if len(literals) != len(tt.generators) {
+			t.Fatalf("expected %d function literals in %q, got %d", len(tt.generators), tt.input, len(literals))
+		}
+		for i, fn := range literals {
+			if fn.IsGenerator != tt.generators[i] {
+				t.Errorf("function literal %d in %q: expected IsGenerator=%t, got %t", i, tt.input, tt.generators[i], fn.IsGenerator)
+			}
+		}
+	}
+
+	l := lexer.New("yield 1")
+	p := New(l)
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected parser error for yield outside of function")
+	}
+}
+
+func TestSpawnExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"spawn f(1, 2)", "spawn f(1, 2)"},
+		{"spawn fn(x) { x }(1)", "spawn fn(x) x(1)"},
+		{"spawn a.b(1)", "spawn (a.b)(1)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.SpawnExpression); !ok {
+			t.Fatalf("stmt.Expression is not *ast.SpawnExpression, got %T", stmt.Expression)
+		}
+		if stmt.String() != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, stmt.String())
+		}
+	}
+
+	l := lexer.New("spawn f")
+	p := New(l)
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Errorf("expected parser error for spawn without call")
+	}
+}
+
+func TestArrowFunctionParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+		expected       string
+	}{
+		{"x => x * 2", []string{"x"}, "(x) => (x * 2)"},
+		{"(x) => x", []string{"x"}, "(x) => x"},
+		{"(a, b) => a + b", []string{"a", "b"}, "(a, b) => (a + b)"},
+		{"() => 1", []string{}, "() => 1"},
+		{"x => { let y = x; y }", []string{"x"}, "(x) => let y = x;y"},
+		{"x => y => x + y", []string{"x"}, "(x) => (y) => (x + y)"},
+		{"x => x |> f", []string{"x"}, "(x) => (x |> f)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not *ast.FunctionLiteral, got %T", stmt.Expression)
+		}
+
+		if len(function.Parameters) != len(tt.expectedParams) {
+			t.Errorf("wrong number of parameters for %q, expected %d, got %d", tt.input, len(tt.expectedParams), len(function.Parameters))
+		}
+		for i, ident := range tt.expectedParams {
+			checkLiteralExpression(t, function.Parameters[i], ident)
+		}
+
+		if function.String() != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, function.String())
+		}
+	}
+}
+
+func TestArrowFunctionErrors(t *testing.T) {
+	tests := []string{
+		"(a, 1) => a",
+		"(a, b)",
+		"() + 1",
+		"x =>",
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parser errors for %q", input)
+		}
+	}
+}
+
+func TestInterpolatedStringParsing(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedParts int
+		expected      string
+	}{
+		{`"hello ${name}!"`, 3, "hello ${name}!"},
+		{`"${a + b}"`, 1, "${(a + b)}"},
+		{`"${a}${b}"`, 2, "${a}${b}"},
+		{`"x ${f("${y}")} z"`, 3, "x ${f(${y})} z"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		str, ok := stmt.Expression.(*ast.InterpolatedString)
+		if !ok {
+			t.Fatalf("exp not *ast.InterpolatedString, got %T", stmt.Expression)
+		}
+		if len(str.Parts) != tt.expectedParts {
+			t.Errorf("wrong number of parts for %s, expected %d, got %d", tt.input, tt.expectedParts, len(str.Parts))
+		}
+		if str.String() != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, str.String())
+		}
+	}
+}
+
+func TestStringLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"abc`, "1:1: Unterminated string literal"},
+		{`let x = "\q";`, "1:10: Unknown escape sequence \\q"},
+		{`let x = 0xZ;`, "1:9: Invalid digit 'Z' in hexadecimal literal 0xZ"},
+		{`let x = 1 @ 2;`, "1:11: Illegal character '@'"},
+		{`"${1 2}"`, "1:6: Expected next token to be TEMPLATE_END, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %s", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("wrong error for %s, expected %q, got %q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input         string
@@ -246,6 +528,15 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		{"add(a + b + c * d / f + g)", "add((((a + b) + ((c * d) / f)) + g))"},
 		{"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
 		{"add(a * b[2], b[1], 2 * [1, 2][1])", "add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))"},
+		{"-a.b * c", "((-(a.b)) * c)"},
+		{"a[1:b + 1] + c[:][-1]", "((a[1:(b + 1)]) + ((c[:])[(-1)]))"},
+		{"a.b.c(d)[e]", "(((a.b).c)(d)[e])"},
+		{"a |> f", "(a |> f)"},
+		{"map(xs, x => x * 2)", "map(xs, (x) => (x * 2))"},
+		{"(a + b) * c", "((a + b) * c)"},
+		{"a + b |> f(c) |> g", "(((a + b) |> f(c)) |> g)"},
+		{"a == b |> f", "((a == b) |> f)"},
+		{"xs |> map(fn(x) { x }) |> a.b(1)", "((xs |> map(fn(x) x)) |> (a.b)(1))"},
 	}
 
 	for _, tt := range tests {
@@ -402,7 +693,8 @@ func TestStringLiteralExpression(t *testing.T) {
 		{input: `"hello\nworld"`, expected: "hello\nworld"},
 		{input: `"hello\t\t\tworld"`, expected: "hello\t\t\tworld"},
 		{input: `"hello\\world"`, expected: "hello\\world"},
-		{input: `"hello\bworld"`, expected: "helloworld"},
+		{input: `"\x41\u{e9}\u{1F600}\$"`, expected: "A\u00e9\U0001F600$"},
+		{input: "`raw\\n\n`", expected: "raw\\n\n"},
 	}
 
 	for _, tt := range tests {
@@ -572,6 +864,67 @@ func TestParsingIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestParsingSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input string
+		start interface{}
+		end   interface{}
+	}{
+		{"myArray[1:2]", 1, 2},
+		{"myArray[:2]", nil, 2},
+		{"myArray[1:]", 1, nil},
+		{"myArray[:]", nil, nil},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, _ := program.Statements[0].(*ast.ExpressionStatement)
+		sliceExp, ok := stmt.Expression.(*ast.SliceExpression)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.SliceExpression, got %T", stmt.Expression)
+		}
+		if !checkIdentifier(t, sliceExp.Left, "myArray") {
+			return
+		}
+
+		for _, bound := range []struct {
+			exp      ast.Expression
+			expected interface{}
+		}{{sliceExp.Start, tt.start}, {sliceExp.End, tt.end}} {
+			if bound.expected == nil {
+				if bound.exp != nil {
+					t.Errorf("bound is not nil, got %T", bound.exp)
+				}
+				continue
+			}
+			checkLiteralExpression(t, bound.exp, bound.expected)
+		}
+	}
+}
+
+func TestParsingMemberExpressions(t *testing.T) {
+	input := "json.parse"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, _ := program.Statements[0].(*ast.ExpressionStatement)
+	member, ok := stmt.Expression.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.MemberExpression, got %T", stmt.Expression)
+	}
+	if !checkIdentifier(t, member.Object, "json") {
+		return
+	}
+	checkIdentifier(t, member.Property, "parse")
+}
+
 func TestParsingHashLiteralsStringKeys(t *testing.T) {
 	input := `{"one": 1, "two": 2, "three": 3}`
 
@@ -856,6 +1209,47 @@ func checkInfixExpression(t *testing.T, exp ast.Expression, left interface{}, op
 	return true
 }
 
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []lexer.Error
+	}{
+		{"let = 5;", []lexer.Error{
+			{Line: 1, Column: 5, Message: "Expected next token to be IDENT, got = instead"},
+			{Line: 1, Column: 5, Message: "No prefix parse function for = found"},
+		}},
+		{"let x = 1;\n  yield x", []lexer.Error{{Line: 2, Column: 3, Message: "Yield outside of function"}}},
+		{"let x = 1 +\n  ;", []lexer.Error{{Line: 2, Column: 3, Message: "No prefix parse function for ; found"}}},
+		{"struct P { x, x }", []lexer.Error{{Line: 1, Column: 15, Message: "Duplicate field x in struct P"}}},
+		{"(a, 1) => a", []lexer.Error{{Line: 1, Column: 5, Message: "Expected identifier as arrow function parameter, got 1"}}},
+		{"spawn 1", []lexer.Error{{Line: 1, Column: 1, Message: "Expected call expression after spawn"}}},
+		{`"abc`, []lexer.Error{{Line: 1, Column: 1, Message: "Unterminated string literal"}}},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.ErrorList()
+		if len(errors) != len(tt.expected) {
+			t.Errorf("%q - wrong number of errors, expected %v, got %v", tt.input, tt.expected, errors)
+			continue
+		}
+		for i, expected := range tt.expected {
+			if errors[i] != expected {
+				t.Errorf("%q - errors[%d] wrong, expected %v, got %v", tt.input, i, expected, errors[i])
+			}
+		}
+		messages := make([]string, len(errors))
+		for i, err := range errors {
+			messages[i] = err.String()
+		}
+		if !reflect.DeepEqual(p.Errors(), messages) {
+			t.Errorf("%q - Errors and ErrorList differ, got %q and %v", tt.input, p.Errors(), errors)
+		}
+	}
+}
+
 func checkParserErrors(t *testing.T, p *Parser) {
 	t.Helper()
 	errors := p.Errors()
@@ -0,0 +1,238 @@
+// repl/commands.go
+
+package repl
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/solbero/monkey/ast"
+	"github.com/solbero/monkey/lexer"
+	"github.com/solbero/monkey/object"
+	"github.com/solbero/monkey/parser"
+	"github.com/solbero/monkey/token"
+)
+
+// treeOrder is the order :ast lists the children of a node in, which follows
+// the source. Other fields come after these in alphabetical order.
+var treeOrder = []string{
+	"name", "condition", "consequence", "alternative", "function", "object",
+	"property", "left", "index", "start", "end", "right", "parameters", "body",
+	"arguments", "elements", "pairs", "key", "value", "parts", "fields",
+	"call", "expression", "statements",
+}
+
+type metaCommand struct {
+	name string
+	args string // synopsis of the argument
+	help string
+	run  func(s *session, arg string)
+}
+
+// metaCommands is set in init, as :help refers back to it.
+var metaCommands []*metaCommand
+
+func init() {
+	metaCommands = []*metaCommand{
+		{"help", "", "show this help", (*session).help},
+		{"env", "", "list the bindings of the session", (*session).listEnv},
+		{"type", "expr", "evaluate expr and show the type of its value", (*session).showType},
+		{"ast", "expr", "show the syntax tree of expr", (*session).showAST},
+		{"tokens", "expr", "show the tokens of expr", (*session).showTokens},
+		{"time", "expr", "evaluate expr and show how long it took", (*session).timeExpr},
+		{"load", "file", "evaluate a file in the session", (*session).load},
+		{"save", "file", "write the inputs evaluated without error to a file", (*session).save},
+		{"reset", "", "remove all bindings and inputs", (*session).reset},
+	}
+}
+
+// command runs a meta-command line such as ":type 1 + 2".
+func (s *session) command(line string) {
+	name, arg, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+	arg = strings.TrimSpace(arg)
+
+	for _, cmd := range metaCommands {
+		if cmd.name != name {
+			continue
+		}
+		if cmd.args != "" && arg == "" {
+			fmt.Fprintf(s.out, "usage: :%s %s\n", cmd.name, cmd.args)
+			return
+		}
+		if cmd.args == "" && arg != "" {
+			fmt.Fprintf(s.out, "usage: :%s\n", cmd.name)
+			return
+		}
+		cmd.run(s, arg)
+		return
+	}
+
+	fmt.Fprintf(s.out, "unknown command :%s, see :help\n", name)
+}
+
+func (s *session) help(string) {
+	for _, cmd := range metaCommands {
+		fmt.Fprintf(s.out, "  %-14s %s\n", strings.TrimSpace(":"+cmd.name+" "+cmd.args), cmd.help)
+	}
+}
+
+func (s *session) listEnv(string) {
+	for _, name := range s.env.Names() {
+		val, _ := s.env.Get(name)
+
+		value := object.Summarize(val.Inspect())
+
+		keyword := "let"
+		if s.env.IsConst(name) {
+			keyword = "const"
+		}
+		fmt.Fprintf(s.out, "%s %s: %s = %s\n", keyword, name, val.Type(), value)
+	}
+}
+
+func (s *session) showType(arg string) {
+	if evaluated, ok := s.evaluate(arg); ok && evaluated != nil {
+		if _, isErr := evaluated.(*object.Error); isErr {
+			fmt.Fprintln(s.out, evaluated.Inspect())
+			return
+		}
+		fmt.Fprintln(s.out, evaluated.Type())
+	}
+}
+
+func (s *session) showAST(arg string) {
+	p := parser.New(lexer.New(arg))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(s.out, p.Errors())
+		return
+	}
+
+	for _, stmt := range program.Statements {
+		s.printTree(ast.Dump(stmt), "", "")
+	}
+}
+
+// printTree prints a node dumped by ast.Dump with its fields on one line and
+// its children indented below it.
+func (s *session) printTree(node interface{}, label, indent string) {
+	fields, ok := node.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := treeIndex(keys[i]), treeIndex(keys[j])
+		if a != b {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+
+	// Hash pairs are the only maps without a type.
+	typ, _ := fields["type"].(string)
+	line := strings.TrimRight(indent+label+typ, " ")
+	var children []string
+	for _, key := range keys {
+		switch value := fields[key].(type) {
+		case map[string]interface{}, []interface{}:
+			children = append(children, key)
+		case nil:
+		case string:
+			if key != "type" {
+				line += fmt.Sprintf(" %s=%q", key, value)
+			}
+		default:
+			if key != "line" && key != "column" {
+				line += fmt.Sprintf(" %s=%v", key, value)
+			}
+		}
+	}
+	if fields["line"] != nil {
+		line += fmt.Sprintf(" (%v:%v)", fields["line"], fields["column"])
+	}
+	fmt.Fprintln(s.out, line)
+
+	for _, key := range children {
+		switch value := fields[key].(type) {
+		case map[string]interface{}:
+			s.printTree(value, key+": ", indent+"  ")
+		case []interface{}:
+			for i, child := range value {
+				s.printTree(child, fmt.Sprintf("%s[%d]: ", key, i), indent+"  ")
+			}
+		}
+	}
+}
+
+func treeIndex(key string) int {
+	for i, k := range treeOrder {
+		if k == key {
+			return i
+		}
+	}
+	return len(treeOrder)
+}
+
+func (s *session) showTokens(arg string) {
+	l := lexer.New(arg)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		fmt.Fprintf(s.out, "%d:%d\t%s\t%q\n", tok.Line, tok.Column, tok.Type, tok.Literal)
+	}
+	for _, msg := range l.Errors() {
+		fmt.Fprintln(s.out, msg)
+	}
+}
+
+func (s *session) timeExpr(arg string) {
+	start := time.Now()
+	evaluated, ok := s.evaluate(arg)
+	elapsed := time.Since(start)
+	if !ok {
+		return
+	}
+
+	if evaluated != nil {
+		fmt.Fprintln(s.out, evaluated.Inspect())
+	}
+	fmt.Fprintf(s.out, "took %s\n", elapsed.Round(time.Microsecond))
+}
+
+func (s *session) load(arg string) {
+	src, err := os.ReadFile(arg)
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+
+	if evaluated, ok := s.evaluate(string(src)); ok {
+		if _, isErr := evaluated.(*object.Error); isErr {
+			fmt.Fprintln(s.out, evaluated.Inspect())
+		}
+	}
+}
+
+func (s *session) save(arg string) {
+	var out strings.Builder
+	for _, input := range s.inputs {
+		out.WriteString(strings.TrimRight(input, "\n") + "\n")
+	}
+
+	if err := os.WriteFile(arg, []byte(out.String()), 0644); err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+	fmt.Fprintf(s.out, "saved %d inputs to %s\n", len(s.inputs), arg)
+}
+
+func (s *session) reset(string) {
+	s.env = object.NewEnvironment()
+	s.inputs = nil
+}
@@ -0,0 +1,88 @@
+// repl/complete.go
+
+package repl
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/solbero/monkey/evaluator"
+	"github.com/solbero/monkey/object"
+	"github.com/solbero/monkey/token"
+)
+
+// complete returns the completions of the word before pos in line, and where
+// that word starts. It completes meta-commands, string keys of a hash after
+// h[" and otherwise bindings, builtins and keywords.
+func (s *session) complete(line []rune, pos int) ([]string, int) {
+	before := line[:pos]
+
+	if len(before) > 0 && before[0] == ':' && !strings.ContainsRune(string(before), ' ') {
+		var names []string
+		for _, cmd := range metaCommands {
+			names = append(names, ":"+cmd.name)
+		}
+		return withPrefix(names, string(before)), 0
+	}
+
+	if m := keyPattern.FindStringSubmatchIndex(string(before)); m != nil {
+		text := string(before)
+		return s.completeKey(text[m[2]:m[3]], text[m[4]:]), utf8.RuneCountInString(text[:m[4]])
+	}
+
+	start := pos
+	for start > 0 && unicode.IsLetter(before[start-1]) {
+		start--
+	}
+	if start == pos || (start > 0 && before[start-1] == '.') {
+		return nil, start
+	}
+
+	names := token.Keywords()
+	names = append(names, evaluator.BuiltinNames()...)
+	for env := s.env; env != nil; env = env.Outer() {
+		names = append(names, env.Names()...)
+	}
+
+	return withPrefix(names, string(before[start:])), start
+}
+
+// keyPattern matches the start of a string index into a hash, e.g. h["ke.
+var keyPattern = regexp.MustCompile(`(\pL+)\["([^"\\]*)$`)
+
+// completeKey returns the string keys of the named hash starting with
+// prefix. The completions close the index expression.
+func (s *session) completeKey(name, prefix string) []string {
+	val, ok := s.env.Get(name)
+	hash, isHash := val.(*object.Hash)
+	if !ok || !isHash {
+		return nil
+	}
+
+	var keys []string
+	for _, pair := range hash.OrderedPairs() {
+		if key, ok := pair.Key.(*object.String); ok && strings.HasPrefix(key.Value, prefix) && !strings.ContainsAny(key.Value, "\"\\\n") {
+			keys = append(keys, key.Value+`"]`)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// withPrefix returns the names starting with prefix, sorted and without
+// duplicates.
+func withPrefix(names []string, prefix string) []string {
+	sort.Strings(names)
+
+	var matches []string
+	for i, name := range names {
+		if strings.HasPrefix(name, prefix) && (i == 0 || names[i-1] != name) {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,318 @@
+// repl/editor.go
+
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// errInterrupt is returned by ReadLine when the input is cancelled with
+// Ctrl-C.
+var errInterrupt = errors.New("interrupt")
+
+// lineReader reads the lines of the REPL input. ReadLine returns io.EOF at
+// the end of the input and errInterrupt if the line was cancelled.
+type lineReader interface {
+	ReadLine(prompt string) (string, error)
+}
+
+// plainReader reads lines without editing, e.g. from a pipe.
+type plainReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (r *plainReader) ReadLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+
+	line, err := r.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// Key codes read by the editor.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyCtrlJ     = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// editor reads lines from a terminal in raw mode, with cursor movement,
+// deletion and history.
+type editor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	raw     func() (func(), error) // switches the terminal to raw mode, nil if already
+	history *history
+
+	// complete returns the completions of the word before pos in line and
+	// where the word starts. It may be nil.
+	complete func(line []rune, pos int) ([]string, int)
+
+	prompt string
+	line   []rune
+	pos    int // cursor position in line
+
+	// index into history.lines of the shown line; len(history.lines) is the
+	// line being entered, which is kept in pending while browsing.
+	index   int
+	pending []rune
+}
+
+func (e *editor) ReadLine(prompt string) (string, error) {
+	if e.raw != nil {
+		restore, err := e.raw()
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	e.prompt = prompt
+	e.line = e.line[:0]
+	e.pos = 0
+	e.index = len(e.history.lines)
+	e.pending = nil
+	e.refresh()
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case keyEnter, keyCtrlJ:
+			fmt.Fprint(e.out, "\n")
+			line := string(e.line)
+			e.history.add(line)
+			return line, nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\n")
+			return "", errInterrupt
+		case keyCtrlD:
+			if len(e.line) == 0 {
+				fmt.Fprint(e.out, "\n")
+				return "", io.EOF
+			}
+			e.delete()
+		case keyCtrlA:
+			e.pos = 0
+		case keyCtrlE:
+			e.pos = len(e.line)
+		case keyCtrlB:
+			e.left()
+		case keyCtrlF:
+			e.right()
+		case keyCtrlH, keyBackspace:
+			if e.pos > 0 {
+				e.pos--
+				e.delete()
+			}
+		case keyCtrlK:
+			e.line = e.line[:e.pos]
+		case keyCtrlU:
+			e.line = append(e.line[:0], e.line[e.pos:]...)
+			e.pos = 0
+		case keyCtrlW:
+			e.deleteWord()
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			e.browse(-1)
+		case keyCtrlN:
+			e.browse(1)
+		case keyEscape:
+			if err := e.escape(); err != nil {
+				return "", err
+			}
+		case keyTab:
+			e.completeWord()
+		default:
+			if unicode.IsPrint(r) {
+				e.insert([]rune{r})
+			}
+		}
+
+		e.refresh()
+	}
+}
+
+// escape handles the escape sequences sent by the arrow, Home, End and
+// Delete keys.
+func (e *editor) escape() error {
+	b, err := e.in.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b != '[' && b != 'O' {
+		return nil
+	}
+
+	var param []byte
+	for {
+		b, err = e.in.ReadByte()
+		if err != nil {
+			return err
+		}
+		if (b < '0' || b > '9') && b != ';' {
+			break
+		}
+		param = append(param, b)
+	}
+
+	switch {
+	case b == 'A':
+		e.browse(-1)
+	case b == 'B':
+		e.browse(1)
+	case b == 'C':
+		e.right()
+	case b == 'D':
+		e.left()
+	case b == 'H', b == '~' && (string(param) == "1" || string(param) == "7"):
+		e.pos = 0
+	case b == 'F', b == '~' && (string(param) == "4" || string(param) == "8"):
+		e.pos = len(e.line)
+	case b == '~' && string(param) == "3":
+		e.delete()
+	}
+	return nil
+}
+
+func (e *editor) insert(runes []rune) {
+	line := make([]rune, 0, len(e.line)+len(runes))
+	line = append(line, e.line[:e.pos]...)
+	line = append(line, runes...)
+	e.line = append(line, e.line[e.pos:]...)
+	e.pos += len(runes)
+}
+
+// completeWord completes the word before the cursor as far as all its
+// completions agree, or lists them if that adds nothing. Without completions
+// a tab after a space indents.
+func (e *editor) completeWord() {
+	var completions []string
+	start := e.pos
+	if e.complete != nil {
+		completions, start = e.complete(e.line, e.pos)
+	}
+
+	if len(completions) == 0 {
+		if e.pos == 0 || e.line[e.pos-1] == ' ' {
+			e.insert([]rune("  "))
+		} else {
+			fmt.Fprint(e.out, "\a")
+		}
+		return
+	}
+
+	prefix := []rune(completions[0])
+	for _, c := range completions[1:] {
+		prefix = commonPrefix(prefix, []rune(c))
+	}
+
+	if len(prefix) > e.pos-start {
+		rest := append([]rune{}, e.line[e.pos:]...)
+		e.line = append(append(e.line[:start], prefix...), rest...)
+		e.pos = start + len(prefix)
+		return
+	}
+
+	fmt.Fprint(e.out, "\n"+strings.Join(completions, "  ")+"\n")
+}
+
+func commonPrefix(a, b []rune) []rune {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
+
+// delete removes the rune under the cursor.
+func (e *editor) delete() {
+	if e.pos < len(e.line) {
+		e.line = append(e.line[:e.pos], e.line[e.pos+1:]...)
+	}
+}
+
+// deleteWord removes the word before the cursor and the spaces after it.
+func (e *editor) deleteWord() {
+	start := e.pos
+	for start > 0 && e.line[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && e.line[start-1] != ' ' {
+		start--
+	}
+	e.line = append(e.line[:start], e.line[e.pos:]...)
+	e.pos = start
+}
+
+func (e *editor) left() {
+	if e.pos > 0 {
+		e.pos--
+	}
+}
+
+func (e *editor) right() {
+	if e.pos < len(e.line) {
+		e.pos++
+	}
+}
+
+// browse shows the line delta steps away in the history.
+func (e *editor) browse(delta int) {
+	index := e.index + delta
+	if index < 0 || index > len(e.history.lines) {
+		return
+	}
+
+	if e.index == len(e.history.lines) {
+		e.pending = append(e.pending[:0], e.line...)
+	}
+	e.index = index
+
+	if index == len(e.history.lines) {
+		e.line = append(e.line[:0], e.pending...)
+	} else {
+		e.line = append(e.line[:0], []rune(e.history.lines[index])...)
+	}
+	e.pos = len(e.line)
+}
+
+// refresh redraws the prompt and line and puts the cursor in place.
+func (e *editor) refresh() {
+	var out bytes.Buffer
+
+	out.WriteString("\r" + e.prompt + string(e.line) + "\x1b[K")
+	if n := len(e.line) - e.pos; n > 0 {
+		fmt.Fprintf(&out, "\x1b[%dD", n)
+	}
+
+	e.out.Write(out.Bytes())
+}
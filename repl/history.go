@@ -0,0 +1,86 @@
+// repl/history.go
+
+package repl
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxHistory is the number of lines kept in the history.
+const maxHistory = 1000
+
+// history holds the lines entered in the REPL, oldest first. If it has a
+// path, lines are loaded from and appended to that file.
+type history struct {
+	lines []string
+	path  string
+}
+
+// historyPath returns the file the history is kept in: $MONKEY_HISTORY, or
+// .monkey_history in the home directory.
+func historyPath() string {
+	if path := os.Getenv("MONKEY_HISTORY"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".monkey_history")
+}
+
+// loadHistory reads the history from path. A missing or unreadable file
+// gives an empty history; an empty path one that is not saved.
+func loadHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		h.lines = append(h.lines, scanner.Text())
+	}
+
+	// Compact the file once it has grown well past the limit.
+	if len(h.lines) > 2*maxHistory {
+		h.lines = h.lines[len(h.lines)-maxHistory:]
+		os.WriteFile(path, []byte(strings.Join(h.lines, "\n")+"\n"), 0600)
+	} else if len(h.lines) > maxHistory {
+		h.lines = h.lines[len(h.lines)-maxHistory:]
+	}
+
+	return h
+}
+
+// add appends a line to the history unless it is blank or repeats the
+// previous line.
+func (h *history) add(line string) {
+	if strings.TrimSpace(line) == "" || (len(h.lines) > 0 && h.lines[len(h.lines)-1] == line) {
+		return
+	}
+
+	h.lines = append(h.lines, line)
+	if len(h.lines) > maxHistory {
+		h.lines = h.lines[1:]
+	}
+
+	if h.path == "" {
+		return
+	}
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	file.WriteString(line + "\n")
+}
@@ -0,0 +1,36 @@
+// repl/input.go
+
+package repl
+
+import (
+	"strings"
+
+	"github.com/solbero/monkey/lexer"
+	"github.com/solbero/monkey/token"
+)
+
+// incomplete reports whether src ends inside brackets or a string, so that
+// the input continues on the next line.
+func incomplete(src string) bool {
+	l := lexer.New(src)
+
+	depth := 0
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACE, token.LBRACKET:
+			depth++
+		case token.RPAREN, token.RBRACE, token.RBRACKET:
+			depth--
+		}
+	}
+	if depth > 0 {
+		return true
+	}
+
+	for _, msg := range l.Errors() {
+		if strings.HasSuffix(msg, "Unterminated string literal") || strings.HasSuffix(msg, "Unterminated raw string literal") {
+			return true
+		}
+	}
+	return false
+}
@@ -4,16 +4,20 @@ package repl
 
 import (
 	"bufio"
-	"fmt"
 	"github.com/solbero/monkey/evaluator"
 	"github.com/solbero/monkey/lexer"
 	"github.com/solbero/monkey/object"
 	"github.com/solbero/monkey/parser"
 	"io"
+	"os"
+	"strings"
 )
 
 const PROMPT = ">> "
 
+// CONTINUATION_PROMPT is shown while input spans several lines.
+const CONTINUATION_PROMPT = ".. "
+
 const MONKEY_FACE = `            __,__
    .--.  .-"     "-.  .--.
   / .. \/  .-. .-.  \/ .. \
@@ -27,33 +31,106 @@ const MONKEY_FACE = `            __,__
            '-----'
 `
 
+// Start runs the REPL. If in is a terminal, lines can be edited and are kept
+// in a history file; input with unclosed brackets or strings continues on the
+// next line. Lines starting with a colon are meta-commands, see :help.
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+	run(newLineReader(in, out), out)
+}
 
-	for {
-		fmt.Fprint(out, PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
-			return
+// newLineReader returns an editor if in is a terminal, and a reader of plain
+// lines otherwise.
+func newLineReader(in io.Reader, out io.Writer) lineReader {
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		return &editor{
+			in:      bufio.NewReader(f),
+			out:     out,
+			raw:     func() (func(), error) { return makeRaw(f.Fd()) },
+			history: loadHistory(historyPath()),
 		}
+	}
+	return &plainReader{in: bufio.NewReader(in), out: out}
+}
 
-		line := scanner.Text()
-		l := lexer.New(line)
-		p := parser.New(l)
+// session is the state of a REPL session.
+type session struct {
+	env    *object.Environment
+	out    io.Writer
+	inputs []string // inputs evaluated without error, for :save
+	done   bool     // set when the session should end
+}
 
-		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+func run(r lineReader, out io.Writer) {
+	s := &session{env: object.NewEnvironment(), out: out}
+	if e, ok := r.(*editor); ok {
+		e.complete = s.complete
+	}
+	var lines []string
+
+	for !s.done {
+		prompt := PROMPT
+		if len(lines) > 0 {
+			prompt = CONTINUATION_PROMPT
+		}
+
+		line, err := r.ReadLine(prompt)
+		if err == errInterrupt {
+			lines = nil
 			continue
 		}
+		if err != nil {
+			// Report the errors of unfinished input.
+			if len(lines) > 0 {
+				s.execute(strings.Join(lines, "\n"))
+			}
+			return
+		}
 
-		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
+		lines = append(lines, line)
+		input := strings.Join(lines, "\n")
+		if incomplete(input) {
+			continue
 		}
+		lines = nil
+
+		s.execute(input)
+	}
+}
+
+// execute runs a meta-command or evaluates input and prints its value.
+func (s *session) execute(input string) {
+	if strings.HasPrefix(strings.TrimSpace(input), ":") {
+		s.command(strings.TrimSpace(input))
+		return
+	}
+
+	if evaluated, ok := s.evaluate(input); ok && evaluated != nil {
+		io.WriteString(s.out, evaluated.Inspect())
+		io.WriteString(s.out, "\n")
+	}
+}
+
+// evaluate parses and evaluates input in the session. It fails if the input
+// has parser errors, which are printed, or calls exit, which ends the session.
+func (s *session) evaluate(input string) (object.Object, bool) {
+	l := lexer.New(input)
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(s.out, p.Errors())
+		return nil, false
+	}
+
+	evaluated := evaluator.Eval(program, s.env)
+	if _, ok := evaluated.(*object.Exit); ok {
+		s.done = true
+		return nil, false
+	}
+	if _, ok := evaluated.(*object.Error); !ok && strings.TrimSpace(input) != "" {
+		s.inputs = append(s.inputs, input)
 	}
+	return evaluated, true
 }
 
 func printParserErrors(out io.Writer, errors []string) {
@@ -0,0 +1,296 @@
+// repl/repl_test.go
+
+package repl
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/solbero/monkey/object"
+)
+
+func TestStart(t *testing.T) {
+	input := "let f = fn(x) {\n  x * 2\n};\nf(21)\n\"a\nb\"\nlet x = 1 +;\nexit()\n1"
+	expected := ">> .. .. >> 42\n>> .. a\nb\n>> parser errors:\n\t1:12: No prefix parse function for ; found\n>> "
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	if out.String() != expected {
+		t.Errorf("output wrong, expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestMetaCommands(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "lib.mk")
+	if err := os.WriteFile(file, []byte("let double = fn(x) { x * 2 };"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := filepath.Join(dir, "session.mk")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{":env", ""},
+		{"let x = 5; const y = \"a\";\n:env", "let x: INTEGER = 5\nconst y: STRING = a\n"},
+		{"let f = fn(a) {\n  a\n};\n:env", "let f: FUNCTION = fn(a) { ...\n"},
+		{"let s = \"" + strings.Repeat("é", 61) + "\";\n:env", "let s: STRING = " + strings.Repeat("é", 60) + " ...\n"},
+		{":type 1 + 2", "INTEGER\n"},
+		{":type 1 + true", "ERROR: type mismatch: INTEGER + BOOLEAN\n"},
+		{":type let", "parser errors:\n\t1:4: Expected next token to be IDENT, got EOF instead\n"},
+		{":type", "usage: :type expr\n"},
+		{":ast -a", "ExpressionStatement (1:1)\n  expression: PrefixExpression operator=\"-\" (1:1)\n    right: Identifier value=\"a\" (1:2)\n"},
+		{":ast {1: f(x)}", "ExpressionStatement (1:1)\n  expression: HashLiteral (1:1)\n    pairs[0]:\n      key: IntegerLiteral value=1 (1:2)\n      value: CallExpression (1:6)\n        function: Identifier value=\"f\" (1:5)\n        arguments[0]: Identifier value=\"x\" (1:7)\n"},
+		{":tokens x + 1", "1:1\tIDENT\t\"x\"\n1:3\t+\t\"+\"\n1:5\tINT\t\"1\"\n"},
+		{":load " + file + "\ndouble(21)", "42\n"},
+		{":load " + filepath.Join(dir, "missing.mk"), "open " + filepath.Join(dir, "missing.mk") + ": no such file or directory\n"},
+		{"let x = 1;\nx + true\nlet y = {\n};\n:save " + saved + "\n:reset\n:load " + saved + "\n:env", "ERROR: type mismatch: INTEGER + BOOLEAN\nsaved 2 inputs to " + saved + "\nlet x: INTEGER = 1\nlet y: HASH = {}\n"},
+		{"let x = 1;\n:reset\nx", "ERROR: identifier not found: x\n"},
+		{":reset x", "usage: :reset\n"},
+		{":foo", "unknown command :foo, see :help\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		run(&plainReader{in: bufio.NewReader(strings.NewReader(tt.input)), out: &out}, &out)
+
+		result := strings.ReplaceAll(strings.ReplaceAll(out.String(), PROMPT, ""), CONTINUATION_PROMPT, "")
+		if result != tt.expected {
+			t.Errorf("%q - output wrong, expected %q, got %q", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestTimeCommand(t *testing.T) {
+	var out bytes.Buffer
+	run(&plainReader{in: bufio.NewReader(strings.NewReader(":time 1 + 2")), out: &out}, &out)
+
+	if !strings.HasPrefix(out.String(), ">> 3\ntook ") {
+		t.Errorf("output wrong, got %q", out.String())
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	var out bytes.Buffer
+	run(&plainReader{in: bufio.NewReader(strings.NewReader(":help")), out: &out}, &out)
+
+	for _, cmd := range metaCommands {
+		if !strings.Contains(out.String(), ":"+cmd.name) {
+			t.Errorf("command :%s missing from help", cmd.name)
+		}
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 + 2", false},
+		{"", false},
+		{"let f = fn(x) {", true},
+		{"let f = fn(x) {\n x }", false},
+		{"[1, 2", true},
+		{"f(1,", true},
+		{"f(1))", false},
+		{`"abc`, true},
+		{`"a ${b`, true},
+		{`"a ${b}`, true},
+		{`"a ${b}"`, false},
+		{"`raw\n", true},
+		{"// {", false},
+		{`"{"`, false},
+	}
+
+	for _, tt := range tests {
+		if result := incomplete(tt.input); result != tt.expected {
+			t.Errorf("incomplete(%q) wrong, expected %t, got %t", tt.input, tt.expected, result)
+		}
+	}
+}
+
+func TestEditor(t *testing.T) {
+	tests := []struct {
+		keys     string
+		history  []string
+		expected string
+		err      error
+	}{
+		{"abc\r", nil, "abc", nil},
+		{"héllo\n", nil, "héllo", nil},
+		{"ac\x1b[Db\r", nil, "abc", nil},
+		{"bc\x01a\x05d\r", nil, "abcd", nil},
+		{"abc\x7f\x7fx\r", nil, "ax", nil},
+		{"abc\x1b[D\x1b[D\x1b[3~\r", nil, "ac", nil},
+		{"abc\x1b[H\x04\r", nil, "bc", nil},
+		{"abc\x1b[D\x1b[D\x0b\r", nil, "a", nil},
+		{"abc\x1b[D\x15\r", nil, "c", nil},
+		{"let x = 1\x17\x17y\r", nil, "let x y", nil},
+		{"\x1b[A\r", []string{"first", "second"}, "second", nil},
+		{"\x1b[A\x1b[A\x1b[A\r", []string{"first", "second"}, "first", nil},
+		{"new\x10\x0e\r", []string{"old"}, "new", nil},
+		{"\x10x\r", []string{"old"}, "oldx", nil},
+		{"abc\x03", nil, "", errInterrupt},
+		{"\x04", nil, "", io.EOF},
+		{"abc", nil, "", io.EOF},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		e := &editor{
+			in:      bufio.NewReader(strings.NewReader(tt.keys)),
+			out:     &out,
+			history: &history{lines: tt.history},
+		}
+
+		line, err := e.ReadLine(PROMPT)
+		if err != tt.err {
+			t.Errorf("%q - error wrong, expected %v, got %v", tt.keys, tt.err, err)
+			continue
+		}
+		if line != tt.expected {
+			t.Errorf("%q - line wrong, expected %q, got %q", tt.keys, tt.expected, line)
+		}
+	}
+}
+
+func TestEditorRefresh(t *testing.T) {
+	var out bytes.Buffer
+	e := &editor{
+		in:      bufio.NewReader(strings.NewReader("ab\x1b[D\r")),
+		out:     &out,
+		history: &history{},
+	}
+
+	if _, err := e.ReadLine(PROMPT); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "\r>> \x1b[K" + "\r>> a\x1b[K" + "\r>> ab\x1b[K" + "\r>> ab\x1b[K\x1b[1D" + "\n"
+	if out.String() != expected {
+		t.Errorf("output wrong, expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestComplete(t *testing.T) {
+	s := &session{env: object.NewEnvironment(), out: io.Discard}
+	s.evaluate(`let lengths = [1]; let person = {"name": "Ann", "nick": "A", "my key": 1, 1: 2}; let f = fn(local) { local }`)
+
+	tests := []struct {
+		line          string
+		expected      []string
+		expectedStart int
+	}{
+		{"le", []string{"len", "lengths", "let"}, 0},
+		{"1 + leng", []string{"lengths"}, 4},
+		{"pers", []string{"person"}, 0},
+		{"st", []string{"startsWith", "str", "struct"}, 0},
+		{"js", []string{"json"}, 0},
+		{"loc", nil, 0},
+		{"x.le", nil, 2},
+		{"1 + ", nil, 4},
+		{`person["`, []string{`my key"]`, `name"]`, `nick"]`}, 8},
+		{`person["n`, []string{`name"]`, `nick"]`}, 8},
+		{`person["my k`, []string{`my key"]`}, 8},
+		{`lengths["`, nil, 9},
+		{`nobody["`, nil, 8},
+		{":t", []string{":time", ":tokens", ":type"}, 0},
+		{":type le", []string{"len", "lengths", "let"}, 6},
+	}
+
+	for _, tt := range tests {
+		line := []rune(tt.line)
+		completions, start := s.complete(line, len(line))
+
+		if strings.Join(completions, ",") != strings.Join(tt.expected, ",") || start != tt.expectedStart {
+			t.Errorf("%q - completions wrong, expected %q at %d, got %q at %d", tt.line, tt.expected, tt.expectedStart, completions, start)
+		}
+	}
+}
+
+func TestEditorCompletion(t *testing.T) {
+	complete := func(line []rune, pos int) ([]string, int) {
+		start := pos
+		for start > 0 && line[start-1] != ' ' {
+			start--
+		}
+		var completions []string
+		for _, name := range []string{"first", "filter", "len"} {
+			if strings.HasPrefix(name, string(line[start:pos])) && start < pos {
+				completions = append(completions, name)
+			}
+		}
+		return completions, start
+	}
+
+	tests := []struct {
+		keys           string
+		expected       string
+		expectedOutput string
+	}{
+		{"le\t(x)\r", "len(x)", ""},
+		{"f\t\r", "fi", ""},
+		{"fi\t\r", "fi", "\nfirst  filter\n"},
+		{"x\t\r", "x", "\a"},
+		{"\tx\r", "  x", ""},
+		{"(x)\x1b[D\x1b[D\x1b[Dl\t\r", "len(x)", ""},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		e := &editor{
+			in:       bufio.NewReader(strings.NewReader(tt.keys)),
+			out:      &out,
+			history:  &history{},
+			complete: complete,
+		}
+
+		line, err := e.ReadLine(PROMPT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != tt.expected {
+			t.Errorf("%q - line wrong, expected %q, got %q", tt.keys, tt.expected, line)
+		}
+		if tt.expectedOutput != "" && !strings.Contains(out.String(), tt.expectedOutput) {
+			t.Errorf("%q - output wrong, expected it to contain %q, got %q", tt.keys, tt.expectedOutput, out.String())
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	h := loadHistory(path)
+	for _, line := range []string{"a", "b", "b", "  ", "c"} {
+		h.add(line)
+	}
+
+	expected := []string{"a", "b", "c"}
+	if got := loadHistory(path).lines; strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("history wrong, expected %q, got %q", expected, got)
+	}
+
+	lines := make([]string, 2*maxHistory+1)
+	for i := range lines {
+		lines[i] = strings.Repeat("x", i%7+1) + string(rune('a'+i%26))
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	h = loadHistory(path)
+	if len(h.lines) != maxHistory || h.lines[maxHistory-1] != lines[len(lines)-1] {
+		t.Fatalf("history not truncated, got %d lines", len(h.lines))
+	}
+	if got := loadHistory(path).lines; len(got) != maxHistory {
+		t.Errorf("history file not compacted, got %d lines", len(got))
+	}
+}
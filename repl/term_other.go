@@ -0,0 +1,16 @@
+// repl/term_other.go
+
+//go:build !linux
+
+package repl
+
+import "errors"
+
+// Line editing is only supported on Linux; elsewhere the REPL reads plain
+// lines.
+
+func isTerminal(fd uintptr) bool { return false }
+
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, errors.New("raw mode not supported")
+}
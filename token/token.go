@@ -2,15 +2,21 @@
 
 package token
 
+import "sort"
+
 const (
 	// Special tokens
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT"
 
 	// Identifiers + literals
-	IDENT  = "IDENT"
-	INT    = "INT"
-	STRING = "STRING"
+	IDENT        = "IDENT"
+	INT          = "INT"
+	STRING       = "STRING"
+	RAW_STRING   = "RAW_STRING"
+	TEMPLATE     = "TEMPLATE"     // string part followed by an interpolation
+	TEMPLATE_END = "TEMPLATE_END" // string part closing an interpolated string
 
 	// Operators
 	ASSIGN   = "="
@@ -23,9 +29,12 @@ const (
 	GT       = ">"
 	EQ       = "=="
 	NOT_EQ   = "!="
+	PIPE     = "|>"
+	ARROW    = "=>"
 
 	// Delimiters
 	COLON     = ":"
+	DOT       = "."
 	SEMICOLON = ";"
 	COMMA     = ","
 	LPAREN    = "("
@@ -38,21 +47,29 @@ const (
 	// Keywords
 	FUNCTION = "FUNCTION"
 	LET      = "LET"
+	CONST    = "CONST"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	STRUCT   = "STRUCT"
+	YIELD    = "YIELD"
+	SPAWN    = "SPAWN"
 )
 
 var keywords = map[string]TokenType{
 	"fn":     FUNCTION,
 	"let":    LET,
+	"const":  CONST,
 	"true":   TRUE,
 	"false":  FALSE,
 	"if":     IF,
 	"else":   ELSE,
 	"return": RETURN,
+	"struct": STRUCT,
+	"yield":  YIELD,
+	"spawn":  SPAWN,
 }
 
 type TokenType string
@@ -60,6 +77,18 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Line    int // line of the first character, starting at 1
+	Column  int // column of the first character in runes, starting at 1
+}
+
+// Keywords returns the keywords of the language in sorted order.
+func Keywords() []string {
+	names := make([]string, 0, len(keywords))
+	for name := range keywords {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func LookupIdent(ident string) TokenType {